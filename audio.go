@@ -0,0 +1,165 @@
+package dodp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Maximum size of an audio answer accepted by the helpers
+const maxAudioSize = 32 << 20
+
+// Accepts reports whether the question accepts answers of the specified input type, e.g. AUDIO.
+func (q *InputQuestion) Accepts(inputType InputType) bool {
+	for _, input := range q.InputTypes.Input {
+		if input.Type == inputType {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsUplinkCodec reports whether the Service accepts audio answers encoded with the codec.
+// RIFF WAVE is supported by every Service and is not listed.
+func (s *ServiceAttributes) SupportsUplinkCodec(codec string) bool {
+	for _, c := range s.SupportedUplinkAudioCodecs.Codec {
+		if c == codec {
+			return true
+		}
+	}
+	return false
+}
+
+// ChooseUplinkCodec returns the first of the codecs available to the Reading System that the Service supports.
+// An empty string means that none of them is supported and the answer must be sent as RIFF WAVE.
+func (s *ServiceAttributes) ChooseUplinkCodec(available ...string) string {
+	for _, codec := range available {
+		if s.SupportsUplinkCodec(codec) {
+			return codec
+		}
+	}
+	return ""
+}
+
+// The fmt chunk of a RIFF WAVE stream
+type wavFormat struct {
+	Size          uint32
+	Format        uint16
+	Channels      uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+// EncodeWAV returns a RIFF WAVE stream with the 16-bit PCM samples.
+// The samples of multiple channels are interleaved.
+func EncodeWAV(samples []int16, sampleRate, channels int) ([]byte, error) {
+	if sampleRate <= 0 || channels <= 0 || len(samples)%channels != 0 {
+		return nil, errors.New("invalid PCM format")
+	}
+	dataSize := 2 * len(samples)
+	buf := bytes.NewBuffer(make([]byte, 0, 44+dataSize))
+	buf.WriteString("RIFF")
+	binary.Write(buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(buf, binary.LittleEndian, wavFormat{
+		Size:          16,
+		Format:        1,
+		Channels:      uint16(channels),
+		SampleRate:    uint32(sampleRate),
+		ByteRate:      uint32(sampleRate * channels * 2),
+		BlockAlign:    uint16(channels * 2),
+		BitsPerSample: 16,
+	})
+	buf.WriteString("data")
+	binary.Write(buf, binary.LittleEndian, uint32(dataSize))
+	binary.Write(buf, binary.LittleEndian, samples)
+	return buf.Bytes(), nil
+}
+
+// ReadWAV reads a RIFF WAVE stream and checks that it contains PCM audio.
+func ReadWAV(r io.Reader) ([]byte, error) {
+	wav, err := io.ReadAll(io.LimitReader(r, maxAudioSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(wav) > maxAudioSize {
+		return nil, errors.New("audio is too large")
+	}
+	if err := checkWAV(wav); err != nil {
+		return nil, err
+	}
+	return wav, nil
+}
+
+func checkWAV(wav []byte) error {
+	if len(wav) < 12 || string(wav[:4]) != "RIFF" || string(wav[8:12]) != "WAVE" {
+		return errors.New("not a RIFF WAVE stream")
+	}
+	var hasFormat, hasData bool
+	for chunks := wav[12:]; len(chunks) >= 8; {
+		id := string(chunks[:4])
+		size := int(binary.LittleEndian.Uint32(chunks[4:8]))
+		if size > len(chunks)-8 {
+			// Some recorders do not update the size of the data chunk, so it is allowed to be truncated
+			size = len(chunks) - 8
+		}
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return errors.New("invalid fmt chunk")
+			}
+			if format := binary.LittleEndian.Uint16(chunks[8:10]); format != 1 && format != 0xFFFE {
+				return fmt.Errorf("unsupported WAVE format: %#x", format)
+			}
+			hasFormat = true
+		case "data":
+			hasData = true
+		}
+		// Chunks are aligned to a word boundary
+		size += size & 1
+		if size > len(chunks)-8 {
+			break
+		}
+		chunks = chunks[8+size:]
+	}
+	if !hasFormat || !hasData {
+		return errors.New("RIFF WAVE stream without fmt or data chunk")
+	}
+	return nil
+}
+
+// NewAudioResponse returns the answer to the question with the RIFF WAVE audio.
+// The question must accept AUDIO answers.
+func NewAudioResponse(q *InputQuestion, wav []byte) (UserResponse, error) {
+	if err := checkWAV(wav); err != nil {
+		return UserResponse{}, err
+	}
+	return newAudioResponse(q, wav)
+}
+
+// NewEncodedAudioResponse returns the answer to the question with audio encoded by a codec other than RIFF WAVE.
+// The question must accept AUDIO answers and the Service must support the codec.
+func NewEncodedAudioResponse(q *InputQuestion, attrs *ServiceAttributes, codec string, audio []byte) (UserResponse, error) {
+	if !attrs.SupportsUplinkCodec(codec) {
+		return UserResponse{}, fmt.Errorf("uplink audio codec not supported by the service: %v", codec)
+	}
+	return newAudioResponse(q, audio)
+}
+
+func newAudioResponse(q *InputQuestion, audio []byte) (UserResponse, error) {
+	if !q.Accepts(AUDIO) {
+		return UserResponse{}, fmt.Errorf("question %v does not accept audio answers", q.ID)
+	}
+	if len(audio) == 0 || len(audio) > maxAudioSize {
+		return UserResponse{}, errors.New("invalid audio size")
+	}
+	return UserResponse{
+		QuestionID: q.ID,
+		Data:       base64.StdEncoding.EncodeToString(audio),
+	}, nil
+}
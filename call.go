@@ -0,0 +1,22 @@
+package dodp
+
+import (
+	"context"
+	"fmt"
+)
+
+// Call performs an arbitrary operation, such as a vendor extension of the protocol.
+// The request and the response must be structures that encoding/xml can marshal and unmarshal as the content of the SOAP Body, with XMLName fields in the namespace of the operation.
+// The call goes through the same machinery as the standard operations: cookies, faults, retries, interceptors and so on.
+// The SOAPAction header is formed from the action by the profile of the client.
+func (c *Client) Call(action string, req, resp any) error {
+	return c.CallContext(c.ctx, action, req, resp)
+}
+
+// CallContext is like Call but uses ctx instead of the client context.
+func (c *Client) CallContext(ctx context.Context, action string, req, resp any) error {
+	if err := c.call(ctx, action, req, resp); err != nil {
+		return fmt.Errorf("%v operation: %w", action, err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package dodp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Single-byte encodings by their names and aliases
+var singleByteCharsets = map[string]*[128]rune{
+	"windows-1250": &windows1250,
+	"cp1250":       &windows1250,
+	"windows-1251": &windows1251,
+	"cp1251":       &windows1251,
+	"windows-1252": &windows1252,
+	"cp1252":       &windows1252,
+	"iso-8859-2":   &iso8859_2,
+	"latin2":       &iso8859_2,
+	"iso-8859-5":   &iso8859_5,
+	"iso-8859-15":  &iso8859_15,
+	"latin-9":      &iso8859_15,
+	"koi8-r":       &koi8r,
+	"koi8-u":       &koi8u,
+	"ibm866":       &ibm866,
+	"cp866":        &ibm866,
+}
+
+var xmlEncoding = regexp.MustCompile(`^<\?xml[^>]*\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// toUTF8 converts the response body to UTF-8.
+// The encoding is determined, in order of priority, by the byte order mark, the charset parameter of the Content-Type and the XML declaration.
+func toUTF8(body []byte, contentType string) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(body, []byte{0xEF, 0xBB, 0xBF}):
+		return body[3:], nil
+	case bytes.HasPrefix(body, []byte{0xFF, 0xFE}):
+		return decodeUTF16(body[2:], binary.LittleEndian), nil
+	case bytes.HasPrefix(body, []byte{0xFE, 0xFF}):
+		return decodeUTF16(body[2:], binary.BigEndian), nil
+	case bytes.HasPrefix(body, []byte{'<', 0, '?', 0}):
+		return decodeUTF16(body, binary.LittleEndian), nil
+	case bytes.HasPrefix(body, []byte{0, '<', 0, '?'}):
+		return decodeUTF16(body, binary.BigEndian), nil
+	}
+
+	charset := ""
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		charset = params["charset"]
+	}
+	if charset == "" {
+		if m := xmlEncoding.FindSubmatch(body); m != nil {
+			charset = string(m[1])
+		}
+	}
+
+	switch charset = strings.ToLower(strings.TrimSpace(charset)); charset {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return body, nil
+	case "iso-8859-1", "latin1", "l1":
+		return decodeLatin1(body), nil
+	case "utf-16le":
+		return decodeUTF16(body, binary.LittleEndian), nil
+	case "utf-16", "utf-16be":
+		// Without a byte order mark UTF-16 is big-endian (RFC 2781)
+		return decodeUTF16(body, binary.BigEndian), nil
+	}
+	if table, ok := singleByteCharsets[charset]; ok {
+		return decodeSingleByte(body, table), nil
+	}
+	return nil, fmt.Errorf("unsupported charset: %v", charset)
+}
+
+// The body is converted to UTF-8 before decoding, so the encoding of the XML declaration is ignored
+func passCharsetReader(label string, input io.Reader) (io.Reader, error) {
+	return input, nil
+}
+
+func decodeLatin1(body []byte) []byte {
+	buf := make([]byte, 0, len(body))
+	for _, b := range body {
+		buf = utf8.AppendRune(buf, rune(b))
+	}
+	return buf
+}
+
+func decodeSingleByte(body []byte, table *[128]rune) []byte {
+	buf := make([]byte, 0, len(body))
+	for _, b := range body {
+		if b < 0x80 {
+			buf = append(buf, b)
+			continue
+		}
+		buf = utf8.AppendRune(buf, table[b-0x80])
+	}
+	return buf
+}
+
+func decodeUTF16(body []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, len(body)/2)
+	for i := range units {
+		units[i] = order.Uint16(body[2*i:])
+	}
+	buf := make([]byte, 0, len(body))
+	for _, r := range utf16.Decode(units) {
+		buf = utf8.AppendRune(buf, r)
+	}
+	return buf
+}
@@ -0,0 +1,121 @@
+package dodp
+
+import (
+	"testing"
+)
+
+func TestToUTF8Charsets(t *testing.T) {
+	tests := []struct {
+		charset string
+		body    string
+		want    string
+	}{
+		{"windows-1250", "P\xf8\xedli\x9a \x9elu\x9dou\xe8k\xfd", "Příliš žluťoučký"},
+		{"windows-1251", "\xcf\xf0\xe8\xe2\xe5\xf2", "Привет"},
+		{"cp1251", "\xcf\xf0\xe8\xe2\xe5\xf2", "Привет"},
+		{"windows-1252", "Gr\xfc\xdfe \x80", "Grüße €"},
+		{"iso-8859-1", "Gr\xfc\xdfe", "Grüße"},
+		{"iso-8859-2", "P\xf8\xedli\xb9 \xbelu\xbbou\xe8k\xfd", "Příliš žluťoučký"},
+		{"iso-8859-5", "\xbf\xe0\xd8\xd2\xd5\xe2", "Привет"},
+		{"iso-8859-15", "Gr\xfc\xdfe \xa4", "Grüße €"},
+		{"koi8-r", "\xf0\xd2\xc9\xd7\xc5\xd4", "Привет"},
+		{"KOI8-R", "\xf0\xd2\xc9\xd7\xc5\xd4", "Привет"},
+		{"koi8-u", "\xf0\xd2\xc9\xd7\xa6\xd4", "Привіт"},
+		{"cp866", "\x8f\xe0\xa8\xa2\xa5\xe2", "Привет"},
+		{"ibm866", "\x8f\xe0\xa8\xa2\xa5\xe2", "Привет"},
+		{"utf-8", "Привет", "Привет"},
+		{"utf-16", "\x04\x1f\x04\x40\x04\x38\x04\x32\x04\x35\x04\x42", "Привет"},
+		{"utf-16be", "\x04\x1f\x04\x40\x04\x38\x04\x32\x04\x35\x04\x42", "Привет"},
+		{"utf-16le", "\x1f\x04\x40\x04\x38\x04\x32\x04\x35\x04\x42\x04", "Привет"},
+	}
+	for _, tt := range tests {
+		got, err := toUTF8([]byte(tt.body), "text/xml; charset="+tt.charset)
+		if err != nil {
+			t.Errorf("%v: %v", tt.charset, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.charset, got, tt.want)
+		}
+	}
+}
+
+func TestToUTF8Detection(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        string
+	}{
+		{
+			name:        "XML declaration only",
+			body:        "<?xml version=\"1.0\" encoding=\"windows-1251\"?><a>\xcf\xf0\xe8\xe2\xe5\xf2</a>",
+			contentType: "text/xml",
+			want:        "<?xml version=\"1.0\" encoding=\"windows-1251\"?><a>Привет</a>",
+		},
+		{
+			name:        "XML declaration with single quotes",
+			body:        "<?xml version='1.0' encoding='koi8-r'?><a>\xf0\xd2\xc9\xd7\xc5\xd4</a>",
+			contentType: "",
+			want:        "<?xml version='1.0' encoding='koi8-r'?><a>Привет</a>",
+		},
+		{
+			name:        "Content-Type overrides XML declaration",
+			body:        "<?xml version=\"1.0\" encoding=\"koi8-r\"?><a>\x8f\xe0\xa8\xa2\xa5\xe2</a>",
+			contentType: "text/xml; charset=cp866",
+			want:        "<?xml version=\"1.0\" encoding=\"koi8-r\"?><a>Привет</a>",
+		},
+		{
+			name:        "no encoding",
+			body:        "<a>Привет</a>",
+			contentType: "text/xml",
+			want:        "<a>Привет</a>",
+		},
+		{
+			name:        "UTF-8 BOM overrides Content-Type",
+			body:        "\xef\xbb\xbf<a>Привет</a>",
+			contentType: "text/xml; charset=windows-1251",
+			want:        "<a>Привет</a>",
+		},
+		{
+			name:        "UTF-16LE BOM",
+			body:        "\xff\xfe<\x00a\x00>\x00\x1f\x04\x40\x04\x38\x04\x32\x04\x35\x04\x42\x04",
+			contentType: "text/xml; charset=utf-16",
+			want:        "<a>Привет",
+		},
+		{
+			name:        "UTF-16BE BOM",
+			body:        "\xfe\xff\x00<\x00a\x00>\x04\x1f\x04\x40\x04\x38\x04\x32\x04\x35\x04\x42",
+			contentType: "text/xml",
+			want:        "<a>Привет",
+		},
+		{
+			name:        "BOM-less UTF-16LE XML declaration",
+			body:        "<\x00?\x00x\x00m\x00l\x00?\x00>\x00\x1f\x04",
+			contentType: "text/xml",
+			want:        "<?xml?>П",
+		},
+		{
+			name:        "BOM-less UTF-16BE XML declaration",
+			body:        "\x00<\x00?\x00x\x00m\x00l\x00?\x00>\x04\x1f",
+			contentType: "text/xml",
+			want:        "<?xml?>П",
+		},
+	}
+	for _, tt := range tests {
+		got, err := toUTF8([]byte(tt.body), tt.contentType)
+		if err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToUTF8UnsupportedCharset(t *testing.T) {
+	if _, err := toUTF8([]byte("<a/>"), "text/xml; charset=x-unknown"); err == nil {
+		t.Error("expected an error for an unsupported charset")
+	}
+}
@@ -5,18 +5,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
 	"time"
 )
 
 // Supported input types
 const (
-	TEXT_NUMERIC      = "TEXT_NUMERIC"
-	TEXT_ALPHANUMERIC = "TEXT_ALPHANUMERIC"
-	AUDIO             = "AUDIO"
+	TEXT_NUMERIC      InputType = "TEXT_NUMERIC"
+	TEXT_ALPHANUMERIC InputType = "TEXT_ALPHANUMERIC"
+	AUDIO             InputType = "AUDIO"
 )
 
 // The identifiers of the content list for getContentList operation
@@ -26,6 +28,11 @@ const (
 	Expired = "expired"
 )
 
+// The Content protection formats for SupportedContentProtectionFormats
+const (
+	PDTB2 ProtectionFormat = "PDTB2"
+)
+
 // The identifiers of the question for getQuestions operation
 const (
 	Default = "default"
@@ -33,16 +40,30 @@ const (
 	Back    = "back"
 )
 
-// SOAP message envelope
+// SOAP message envelope.
+// The name is set by the profile when marshalling, because it depends on the SOAP version.
 type envelope struct {
-	XMLName xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"`
+	XMLName xml.Name
+	Header  *header
 	Body    body
 }
 
+// Namespaces of the SOAP 1.1 and SOAP 1.2 envelopes
+const (
+	soapEnvelopeNS   = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12EnvelopeNS = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+func isEnvelopeNS(ns string) bool {
+	return ns == soapEnvelopeNS || ns == soap12EnvelopeNS
+}
+
 // SOAP message body
 type body struct {
 	XMLName xml.Name `xml:"Body"`
 	Content any
+	// Fault is set instead of filling Content if the body contains a SOAP fault
+	Fault *Fault `xml:"-"`
 }
 
 func (b *body) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
@@ -57,7 +78,21 @@ func (b *body) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		switch v := token.(type) {
 		// We unmarshal only the first element inside the body as content. All other elements, if present, are ignored
 		case xml.StartElement:
-			if err := d.DecodeElement(b.Content, &v); err != nil {
+			if v.Name.Space == soap12EnvelopeNS && v.Name.Local == "Fault" {
+				fault := &soap12Fault{}
+				if err := d.DecodeElement(fault, &v); err != nil {
+					return err
+				}
+				b.Fault = fault.toFault()
+				return d.Skip()
+			}
+			var content any = b.Content
+			// Some services send an unqualified Fault element, which is treated as a SOAP 1.1 fault
+			if v.Name.Local == "Fault" && (v.Name.Space == soapEnvelopeNS || v.Name.Space == "") {
+				b.Fault = &Fault{}
+				content = b.Fault
+			}
+			if err := d.DecodeElement(content, &v); err != nil {
 				return err
 			}
 			return d.Skip()
@@ -65,21 +100,28 @@ func (b *body) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	}
 }
 
-// SOAP fault
-type Fault struct {
-	XMLName     xml.Name `xml:"Fault"`
-	Faultstring string   `xml:"faultstring"`
-}
-
-func (f *Fault) Error() string {
-	return f.Faultstring
-}
-
 // DAISY Online client
 type Client struct {
-	url        string
-	httpClient *http.Client
-	ctx        context.Context
+	url         string
+	httpClient  *http.Client
+	ctx         context.Context
+	header      http.Header
+	profile     Profile
+	limits      Limits
+	compression Compression
+	stats       transferStats
+	dryRun      *Recorder
+	retry       *RetryPolicy
+	relogin     relogin
+	gating      gating
+
+	interceptors     []Interceptor
+	disableRedaction bool
+	instrumentation  Instrumentation
+
+	headerBlocks []HeaderBlock
+	understood   map[xml.Name]bool
+	headerHook   func(action string, blocks []HeaderBlock) error
 }
 
 func NewClient(url string, timeout time.Duration) *Client {
@@ -89,64 +131,200 @@ func NewClient(url string, timeout time.Duration) *Client {
 // Creates an instance of a new DAISY Online client with context and the specified service URL.
 // Timeout limits the execution time of each HTTP request for this client.
 // Zero timeout means no timeout.
+// The context is used by all operations, except for the ones with the Context suffix that take their own context.
 func NewClientWithContext(ctx context.Context, url string, timeout time.Duration) *Client {
-	jar, err := cookiejar.New(nil)
+	// The arguments are not validated, as before the options were introduced
+	c, err := newClient(url, func(c *Client) error {
+		c.ctx = ctx
+		c.httpClient.Timeout = timeout
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// NewClientWithOptions creates an instance of a new DAISY Online client for the specified service URL configured by the options.
+// The URL must be an absolute http or https URL.
+// Without options the client uses the background context, no timeout and an in-memory cookie jar.
+func NewClientWithOptions(serviceURL string, opts ...Option) (*Client, error) {
+	u, err := url.ParseRequestURI(serviceURL)
 	if err != nil {
-		panic("Invalid cookie jar")
+		return nil, fmt.Errorf("service URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("service URL: %v is not an absolute http or https URL", serviceURL)
 	}
+	return newClient(serviceURL, opts...)
+}
 
-	return &Client{
-		url: url,
-		httpClient: &http.Client{
-			Jar:     jar,
-			Timeout: timeout,
-		},
-		ctx: ctx,
+func newClient(serviceURL string, opts ...Option) (*Client, error) {
+	c := &Client{
+		url:        serviceURL,
+		httpClient: &http.Client{},
+		ctx:        context.Background(),
+		header:     make(http.Header),
+		limits:     DefaultLimits,
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
 	}
+
+	// DAISY Online sessions are based on cookies, so the client always needs a jar
+	if c.httpClient.Jar == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, fmt.Errorf("cookie jar: %w", err)
+		}
+		c.httpClient.Jar = jar
+	}
+	return c, nil
 }
 
-func (c *Client) call(action string, args any, rs any) error {
-	var reqEnv envelope
-	reqEnv.Body.Content = args
+func (c *Client) call(ctx context.Context, action string, args any, rs any) error {
+	return c.callGated(ctx, action, func() error {
+		return c.callWithRelogin(ctx, action, func() error {
+			return c.callWithRetry(ctx, action, func() error {
+				return c.roundTrip(ctx, action, args, rs)
+			})
+		})
+	})
+}
 
-	buf := bytes.NewBufferString(xml.Header)
-	enc := xml.NewEncoder(buf)
-	if err := enc.Encode(reqEnv); err != nil {
+func (c *Client) roundTrip(ctx context.Context, action string, args any, rs any) error {
+	reqBody, err := c.profile.marshal(c.requestHeader(ctx), args)
+	if err != nil {
 		return err
 	}
-	if err := enc.Close(); err != nil {
-		return err
+	if captureEnvelope(ctx, reqBody) {
+		return errEnvelopeCaptured
+	}
+
+	ex := &Exchange{Action: action, Request: reqBody}
+	if r, ok := args.(interface{ contentID() string }); ok {
+		ex.ContentID = r.contentID()
+	}
+	if action == "logOn" && !c.disableRedaction {
+		ex.Request = redactPassword(reqBody)
+	}
+	if c.instrumentation != nil {
+		ctx = c.instrumentation.StartRequest(ctx, ex)
+	}
+	err = c.intercept(ctx, ex, func() error {
+		return c.exchange(ctx, ex, reqBody, rs)
+	})
+	if c.instrumentation != nil {
+		c.instrumentation.EndRequest(ctx, ex, err)
 	}
+	return err
+}
 
-	req, err := http.NewRequestWithContext(c.ctx, http.MethodPost, c.url, buf)
+// exchange sends the marshalled request and decodes the response into rs, recording the details in ex
+func (c *Client) exchange(ctx context.Context, ex *Exchange, reqBody []byte, rs any) error {
+	wireBody, contentEncoding, err := compressBody(c.compression, reqBody)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(wireBody))
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
-	req.Header.Set("Accept", "text/xml")
-	req.Header.Set("SOAPAction", "/"+action)
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	c.profile.setHeaders(req.Header, ex.Action)
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+	// Setting Accept-Encoding disables the transparent decompression of the transport, so the response is decompressed by the client
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	if c.dryRun != nil {
+		c.dryRun.record(RecordedRequest{Action: ex.Action, Header: req.Header.Clone(), Envelope: ex.Request})
+		return ErrDryRun
+	}
+	ex.RequestWireSize = int64(len(wireBody))
+	c.stats.requests.Add(1)
+	c.stats.requestBytes.Add(int64(len(reqBody)))
+	c.stats.requestWireBytes.Add(ex.RequestWireSize)
 
+	ex.Start = time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		ex.Duration = time.Since(ex.Start)
 		return err
 	}
 	defer resp.Body.Close()
 
-	var respEnv envelope
-	dec := xml.NewDecoder(resp.Body)
+	wire := &countingReader{r: resp.Body}
+	respBody, err := c.readResponse(resp, wire)
+	ex.Duration = time.Since(ex.Start)
+	ex.StatusCode = resp.StatusCode
+	ex.Header = resp.Header
+	ex.Response = respBody
+	ex.ResponseWireSize = wire.n
+	c.stats.responseBytes.Add(int64(len(respBody)))
+	c.stats.responseWireBytes.Add(wire.n)
+	if err != nil {
+		return err
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		fault := &Fault{}
-		respEnv.Body.Content = fault
-		if err := dec.Decode(&respEnv); err != nil {
-			return err
-		}
-		return fmt.Errorf("fault: %w", fault)
+	snippet := respBody
+	if len(snippet) > maxErrorBodySize {
+		snippet = snippet[:maxErrorBodySize]
+	}
+	httpErr := &HTTPError{
+		Operation:   ex.Action,
+		StatusCode:  resp.StatusCode,
+		Status:      resp.Status,
+		ContentType: resp.Header.Get("Content-Type"),
+		Header:      resp.Header,
+		Body:        string(snippet),
 	}
 
+	decoded, err := toUTF8(respBody, httpErr.ContentType)
+	if err != nil {
+		httpErr.Err = err
+		return httpErr
+	}
+	if err := c.limits.check(decoded); err != nil {
+		return err
+	}
+
+	var respEnv envelope
 	respEnv.Body.Content = rs
-	return dec.Decode(&respEnv)
+	dec := xml.NewDecoder(bytes.NewReader(decoded))
+	dec.CharsetReader = passCharsetReader
+	err = dec.Decode(&respEnv)
+	// Only a response that is not a SOAP envelope at all means an outage; a valid envelope with unexpected content is a protocol error
+	isEnvelope := respEnv.XMLName.Local == "Envelope" && isEnvelopeNS(respEnv.XMLName.Space)
+	var syntaxErr *xml.SyntaxError
+	if err != nil && (!isEnvelope || errors.As(err, &syntaxErr)) {
+		httpErr.Err = err
+		return httpErr
+	}
+	if err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	if !isEnvelope {
+		httpErr.Err = fmt.Errorf("unexpected root element %v", respEnv.XMLName.Local)
+		return httpErr
+	}
+
+	// Some services return a fault with the 200 status code, so the body is checked regardless of the status
+	if respEnv.Body.Fault != nil {
+		return fmt.Errorf("fault: %w", respEnv.Body.Fault)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return httpErr
+	}
+	if respEnv.Header != nil {
+		return c.processResponseHeader(ex.Action, respEnv.Header.Blocks)
+	}
+	return nil
 }
 
 type logOn struct {
@@ -162,15 +340,23 @@ type logOnResponse struct {
 
 // Logs a Reading System on to a Service.
 func (c *Client) LogOn(username, password string) (bool, error) {
+	return c.LogOnContext(c.ctx, username, password)
+}
+
+// LogOnContext is like LogOn but uses ctx instead of the client context.
+func (c *Client) LogOnContext(ctx context.Context, username, password string) (bool, error) {
 	action := "logOn"
 	req := logOn{
 		Username: username,
 		Password: password,
 	}
 	resp := logOnResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return false, fmt.Errorf("%v operation: %w", action, err)
 	}
+	if resp.LogOnResult {
+		c.relogin.setCredentials(&Credentials{Username: username, Password: password})
+	}
 	return resp.LogOnResult, nil
 }
 
@@ -186,13 +372,21 @@ type logOffResponse struct {
 // Logs a Reading System off a Service.
 // A return value of false or a Fault both indicate that the operation was not successful.
 func (c *Client) LogOff() (bool, error) {
+	return c.LogOffContext(c.ctx)
+}
+
+// LogOffContext is like LogOff but uses ctx instead of the client context.
+func (c *Client) LogOffContext(ctx context.Context) (bool, error) {
 	action := "logOff"
 	req := logOff{}
 	resp := logOffResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return false, fmt.Errorf("%v operation: %w", action, err)
 	}
 	c.httpClient.CloseIdleConnections()
+	if resp.LogOffResult {
+		c.relogin.reset()
+	}
 	return resp.LogOffResult, nil
 }
 
@@ -208,12 +402,18 @@ type getServiceAttributesResponse struct {
 // Retrieves Service properties, including information on which optional Operations the Service supports.
 // A Reading System must call this operation as part of the Session Initialization Sequence and may call the operation to retrieve information on possible changes to Service properties at any other time during a Session.
 func (c *Client) GetServiceAttributes() (*ServiceAttributes, error) {
+	return c.GetServiceAttributesContext(c.ctx)
+}
+
+// GetServiceAttributesContext is like GetServiceAttributes but uses ctx instead of the client context.
+func (c *Client) GetServiceAttributesContext(ctx context.Context) (*ServiceAttributes, error) {
 	action := "getServiceAttributes"
 	req := getServiceAttributes{}
 	resp := getServiceAttributesResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return nil, fmt.Errorf("%v operation: %w", action, err)
 	}
+	c.gating.setServiceAttributes(&resp.ServiceAttributes)
 	return &resp.ServiceAttributes, nil
 }
 
@@ -230,12 +430,20 @@ type setReadingSystemAttributesResponse struct {
 // Sends Reading System properties to a Service.
 // A Reading System must call this operation as part of the Session Initialization Sequence. The operation may be called additional times during a Session to record dynamic changes in a Reading System's properties.
 func (c *Client) SetReadingSystemAttributes(readingSystemAttributes *ReadingSystemAttributes) (bool, error) {
+	return c.SetReadingSystemAttributesContext(c.ctx, readingSystemAttributes)
+}
+
+// SetReadingSystemAttributesContext is like SetReadingSystemAttributes but uses ctx instead of the client context.
+func (c *Client) SetReadingSystemAttributesContext(ctx context.Context, readingSystemAttributes *ReadingSystemAttributes) (bool, error) {
 	action := "setReadingSystemAttributes"
 	req := setReadingSystemAttributes{ReadingSystemAttributes: readingSystemAttributes}
 	resp := setReadingSystemAttributesResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return false, fmt.Errorf("%v operation: %w", action, err)
 	}
+	if resp.SetReadingSystemAttributesResult {
+		c.relogin.setReadingSystemAttributes(readingSystemAttributes)
+	}
 	return resp.SetReadingSystemAttributesResult, nil
 }
 
@@ -246,6 +454,10 @@ type getContentList struct {
 	LastItem  int32    `xml:"lastItem"`
 }
 
+func (r getContentList) contentID() string {
+	return r.ID
+}
+
 type getContentListResponse struct {
 	XMLName     xml.Name    `xml:"http://www.daisy.org/ns/daisy-online/ getContentListResponse"`
 	ContentList ContentList `xml:"contentList"`
@@ -255,6 +467,11 @@ type getContentListResponse struct {
 // The list returned by the Service can be pre-composed, in which case it is retrieved by passing one of the three reserved values defined in the id parameter below. (Refer to 4, Protocol Fundamentals for information on the contexts in which these reserved values are used.)
 // The list can also be dynamic (e.g., the result of a dynamic menu search operation sequence). In this case, the id value used to refer to the list is provided in the return value of a previous call to getQuestions. (Refer to the questions type for more information.)
 func (c *Client) GetContentList(id string, firstItem int32, lastItem int32) (*ContentList, error) {
+	return c.GetContentListContext(c.ctx, id, firstItem, lastItem)
+}
+
+// GetContentListContext is like GetContentList but uses ctx instead of the client context.
+func (c *Client) GetContentListContext(ctx context.Context, id string, firstItem int32, lastItem int32) (*ContentList, error) {
 	action := "getContentList"
 	req := getContentList{
 		ID:        id,
@@ -262,7 +479,7 @@ func (c *Client) GetContentList(id string, firstItem int32, lastItem int32) (*Co
 		LastItem:  lastItem,
 	}
 	resp := getContentListResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return nil, fmt.Errorf("%v operation: %w", action, err)
 	}
 	return &resp.ContentList, nil
@@ -273,6 +490,10 @@ type getContentMetadata struct {
 	ContentID string   `xml:"contentID"`
 }
 
+func (r getContentMetadata) contentID() string {
+	return r.ContentID
+}
+
 type getContentMetadataResponse struct {
 	XMLName         xml.Name        `xml:"http://www.daisy.org/ns/daisy-online/ getContentMetadataResponse"`
 	ContentMetadata ContentMetadata `xml:"contentMetadata"`
@@ -281,10 +502,15 @@ type getContentMetadataResponse struct {
 // Retrieves the contentMetadata of the specified Content item.
 // This operation must be called as part of the Content Retrieval Sequence.
 func (c *Client) GetContentMetadata(contentID string) (*ContentMetadata, error) {
+	return c.GetContentMetadataContext(c.ctx, contentID)
+}
+
+// GetContentMetadataContext is like GetContentMetadata but uses ctx instead of the client context.
+func (c *Client) GetContentMetadataContext(ctx context.Context, contentID string) (*ContentMetadata, error) {
 	action := "getContentMetadata"
 	req := getContentMetadata{ContentID: contentID}
 	resp := getContentMetadataResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return nil, fmt.Errorf("%v operation: %w", action, err)
 	}
 	return &resp.ContentMetadata, nil
@@ -295,6 +521,10 @@ type getContentResources struct {
 	ContentID string   `xml:"contentID"`
 }
 
+func (r getContentResources) contentID() string {
+	return r.ContentID
+}
+
 type getContentResourcesResponse struct {
 	XMLName   xml.Name  `xml:"http://www.daisy.org/ns/daisy-online/ getContentResourcesResponse"`
 	Resources Resources `xml:"resources"`
@@ -303,10 +533,15 @@ type getContentResourcesResponse struct {
 // Retrieves the resources list for the specified Content item.
 // The Content item must be issued before this operation is called. If not, the Service shall respond with an invalidParameter Fault.
 func (c *Client) GetContentResources(contentID string) (*Resources, error) {
+	return c.GetContentResourcesContext(c.ctx, contentID)
+}
+
+// GetContentResourcesContext is like GetContentResources but uses ctx instead of the client context.
+func (c *Client) GetContentResourcesContext(ctx context.Context, contentID string) (*Resources, error) {
 	action := "getContentResources"
 	req := getContentResources{ContentID: contentID}
 	resp := getContentResourcesResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return nil, fmt.Errorf("%v operation: %w", action, err)
 	}
 	return &resp.Resources, nil
@@ -317,6 +552,10 @@ type issueContent struct {
 	ContentID string   `xml:"contentID"`
 }
 
+func (r issueContent) contentID() string {
+	return r.ContentID
+}
+
 type issueContentResponse struct {
 	XMLName            xml.Name `xml:"http://www.daisy.org/ns/daisy-online/ issueContentResponse"`
 	IssueContentResult bool     `xml:"issueContentResult"`
@@ -324,10 +563,15 @@ type issueContentResponse struct {
 
 // Requests a Service to issue the specified Content item.
 func (c *Client) IssueContent(contentID string) (bool, error) {
+	return c.IssueContentContext(c.ctx, contentID)
+}
+
+// IssueContentContext is like IssueContent but uses ctx instead of the client context.
+func (c *Client) IssueContentContext(ctx context.Context, contentID string) (bool, error) {
 	action := "issueContent"
 	req := issueContent{ContentID: contentID}
 	resp := issueContentResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return false, fmt.Errorf("%v operation: %w", action, err)
 	}
 	return resp.IssueContentResult, nil
@@ -338,6 +582,10 @@ type returnContent struct {
 	ContentID string   `xml:"contentID"`
 }
 
+func (r returnContent) contentID() string {
+	return r.ContentID
+}
+
 type returnContentResponse struct {
 	XMLName             xml.Name `xml:"http://www.daisy.org/ns/daisy-online/ returnContentResponse"`
 	ReturnContentResult bool     `xml:"returnContentResult"`
@@ -348,10 +596,15 @@ type returnContentResponse struct {
 // A Reading System must not call this function for a Content item that has a requiresReturn attribute with a value of false.
 // A Reading System must delete the Content item before calling returnContent. A Reading System must not call returnContent for a Content item that was not issued to the User on that Reading System.
 func (c *Client) ReturnContent(contentID string) (bool, error) {
+	return c.ReturnContentContext(c.ctx, contentID)
+}
+
+// ReturnContentContext is like ReturnContent but uses ctx instead of the client context.
+func (c *Client) ReturnContentContext(ctx context.Context, contentID string) (bool, error) {
 	action := "returnContent"
 	req := returnContent{ContentID: contentID}
 	resp := returnContentResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return false, fmt.Errorf("%v operation: %w", action, err)
 	}
 	return resp.ReturnContentResult, nil
@@ -369,10 +622,15 @@ type getQuestionsResponse struct {
 
 // Retrieves a question from the series of questions that comprise the dynamic menu system.
 func (c *Client) GetQuestions(userResponses *UserResponses) (*Questions, error) {
+	return c.GetQuestionsContext(c.ctx, userResponses)
+}
+
+// GetQuestionsContext is like GetQuestions but uses ctx instead of the client context.
+func (c *Client) GetQuestionsContext(ctx context.Context, userResponses *UserResponses) (*Questions, error) {
 	action := "getQuestions"
 	req := getQuestions{UserResponses: userResponses}
 	resp := getQuestionsResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return nil, fmt.Errorf("%v operation: %w", action, err)
 	}
 	return &resp.Questions, nil
@@ -389,10 +647,15 @@ type getServiceAnnouncementsResponse struct {
 
 // Retrieves any announcements from the Service that a User has not yet read.
 func (c *Client) GetServiceAnnouncements() (*Announcements, error) {
+	return c.GetServiceAnnouncementsContext(c.ctx)
+}
+
+// GetServiceAnnouncementsContext is like GetServiceAnnouncements but uses ctx instead of the client context.
+func (c *Client) GetServiceAnnouncementsContext(ctx context.Context) (*Announcements, error) {
 	action := "getServiceAnnouncements"
 	req := getServiceAnnouncements{}
 	resp := getServiceAnnouncementsResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return nil, fmt.Errorf("%v operation: %w", action, err)
 	}
 	return &resp.Announcements, nil
@@ -404,6 +667,10 @@ type setBookmarks struct {
 	BookmarkSet *BookmarkSet `xml:"bookmarkSet"`
 }
 
+func (r setBookmarks) contentID() string {
+	return r.ContentID
+}
+
 type setBookmarksResponse struct {
 	XMLName            xml.Name `xml:"http://www.daisy.org/ns/daisy-online/ setBookmarksResponse"`
 	SetBookmarksResult bool     `xml:"setBookmarksResult"`
@@ -412,13 +679,18 @@ type setBookmarksResponse struct {
 // Requests that a Service store the supplied bookmarks for a Content item.
 // This operation only supports the storage of bookmarks for one Content item at a time.
 func (c *Client) SetBookmarks(contentID string, bookmarkSet *BookmarkSet) (bool, error) {
+	return c.SetBookmarksContext(c.ctx, contentID, bookmarkSet)
+}
+
+// SetBookmarksContext is like SetBookmarks but uses ctx instead of the client context.
+func (c *Client) SetBookmarksContext(ctx context.Context, contentID string, bookmarkSet *BookmarkSet) (bool, error) {
 	action := "setBookmarks"
 	req := setBookmarks{
 		ContentID:   contentID,
 		BookmarkSet: bookmarkSet,
 	}
 	resp := setBookmarksResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return false, fmt.Errorf("%v operation: %w", action, err)
 	}
 	return resp.SetBookmarksResult, nil
@@ -429,6 +701,10 @@ type getBookmarks struct {
 	ContentID string   `xml:"contentID"`
 }
 
+func (r getBookmarks) contentID() string {
+	return r.ContentID
+}
+
 type getBookmarksResponse struct {
 	XMLName     xml.Name    `xml:"http://www.daisy.org/ns/daisy-online/ getBookmarksResponse"`
 	BookmarkSet BookmarkSet `xml:"bookmarkSet"`
@@ -436,10 +712,15 @@ type getBookmarksResponse struct {
 
 // Retrieves the bookmarks for a Content item from a Service.
 func (c *Client) GetBookmarks(contentID string) (*BookmarkSet, error) {
+	return c.GetBookmarksContext(c.ctx, contentID)
+}
+
+// GetBookmarksContext is like GetBookmarks but uses ctx instead of the client context.
+func (c *Client) GetBookmarksContext(ctx context.Context, contentID string) (*BookmarkSet, error) {
 	action := "getBookmarks"
 	req := getBookmarks{ContentID: contentID}
 	resp := getBookmarksResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return nil, fmt.Errorf("%v operation: %w", action, err)
 	}
 	return &resp.BookmarkSet, nil
@@ -458,11 +739,44 @@ type markAnnouncementsAsReadResponse struct {
 // Marks the specified announcement(s) as read.
 // This operation is only valid if a previous call to  getServiceAnnouncements  has been made during the Session.
 func (c *Client) MarkAnnouncementsAsRead(read *Read) (bool, error) {
+	return c.MarkAnnouncementsAsReadContext(c.ctx, read)
+}
+
+// MarkAnnouncementsAsReadContext is like MarkAnnouncementsAsRead but uses ctx instead of the client context.
+func (c *Client) MarkAnnouncementsAsReadContext(ctx context.Context, read *Read) (bool, error) {
 	action := "markAnnouncementsAsRead"
 	req := markAnnouncementsAsRead{Read: read}
 	resp := markAnnouncementsAsReadResponse{}
-	if err := c.call(action, req, &resp); err != nil {
+	if err := c.call(ctx, action, req, &resp); err != nil {
 		return false, fmt.Errorf("%v operation: %w", action, err)
 	}
 	return resp.MarkAnnouncementsAsReadResult, nil
 }
+
+type getKeyExchangeObject struct {
+	XMLName          xml.Name `xml:"http://www.daisy.org/ns/daisy-online/ getKeyExchangeObject"`
+	RequestedKeyName string   `xml:"requestedKeyName"`
+}
+
+type getKeyExchangeObjectResponse struct {
+	XMLName     xml.Name    `xml:"http://www.daisy.org/ns/daisy-online/ getKeyExchangeObjectResponse"`
+	KeyExchange KeyExchange `xml:"http://www.daisy.org/DRM/2005/KeyExchange KeyExchange"`
+}
+
+// Requests a PDTB2 Key Exchange Object from a Service.
+// The requestedKeyName is the name of the key of the Reading System, which the Service uses to encrypt the keys in the returned object.
+// This operation is optional and available only if the Service supports PDTB2_KEY_PROVISION. The Reading System should declare PDTB2 in SupportedContentProtectionFormats.
+func (c *Client) GetKeyExchangeObject(requestedKeyName string) (*KeyExchange, error) {
+	return c.GetKeyExchangeObjectContext(c.ctx, requestedKeyName)
+}
+
+// GetKeyExchangeObjectContext is like GetKeyExchangeObject but uses ctx instead of the client context.
+func (c *Client) GetKeyExchangeObjectContext(ctx context.Context, requestedKeyName string) (*KeyExchange, error) {
+	action := "getKeyExchangeObject"
+	req := getKeyExchangeObject{RequestedKeyName: requestedKeyName}
+	resp := getKeyExchangeObjectResponse{}
+	if err := c.call(ctx, action, req, &resp); err != nil {
+		return nil, fmt.Errorf("%v operation: %w", action, err)
+	}
+	return &resp.KeyExchange, nil
+}
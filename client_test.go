@@ -0,0 +1,35 @@
+package dodp
+
+import (
+	"testing"
+)
+
+func TestNewClientWithOptionsURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"http://example.com/service", true},
+		{"https://example.com:8443/dodp/", true},
+		{"HTTPS://example.com/", true},
+		{"", false},
+		{"foo", false},
+		{"/service", false},
+		{"example.com/service", false},
+		{"ftp://example.com/", false},
+		{"http:///service", false},
+		{"http://a b/", false},
+	}
+	for _, tt := range tests {
+		_, err := NewClientWithOptions(tt.url)
+		if valid := err == nil; valid != tt.valid {
+			t.Errorf("%q: got error %v, want valid %v", tt.url, err, tt.valid)
+		}
+	}
+}
+
+func TestLegacyConstructorsDoNotValidate(t *testing.T) {
+	// The constructors without an error result accepted any arguments before the options were introduced
+	NewClient("http://a b/", 0)
+	NewClient("", -1)
+}
@@ -0,0 +1,134 @@
+package dodp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Compression is a content coding of the request bodies.
+type Compression int
+
+const (
+	NoCompression Compression = iota
+	GzipCompression
+	DeflateCompression
+)
+
+// WithRequestCompression makes the client compress the request bodies.
+// It should be used only with services that accept compressed requests.
+// Compressed responses are handled regardless of this option.
+func WithRequestCompression(compression Compression) Option {
+	return func(c *Client) error {
+		if compression < NoCompression || compression > DeflateCompression {
+			return fmt.Errorf("unknown compression: %v", compression)
+		}
+		c.compression = compression
+		return nil
+	}
+}
+
+// TransferStats contains the totals of the data transferred by the client.
+// The wire sizes are the sizes of the bodies after compression.
+type TransferStats struct {
+	Requests          int64
+	RequestBytes      int64
+	RequestWireBytes  int64
+	ResponseBytes     int64
+	ResponseWireBytes int64
+}
+
+type transferStats struct {
+	requests          atomic.Int64
+	requestBytes      atomic.Int64
+	requestWireBytes  atomic.Int64
+	responseBytes     atomic.Int64
+	responseWireBytes atomic.Int64
+}
+
+// TransferStats returns the totals of the data transferred since the client was created.
+func (c *Client) TransferStats() TransferStats {
+	return TransferStats{
+		Requests:          c.stats.requests.Load(),
+		RequestBytes:      c.stats.requestBytes.Load(),
+		RequestWireBytes:  c.stats.requestWireBytes.Load(),
+		ResponseBytes:     c.stats.responseBytes.Load(),
+		ResponseWireBytes: c.stats.responseWireBytes.Load(),
+	}
+}
+
+// compressBody returns the body compressed with the specified method and its content coding
+func compressBody(compression Compression, body []byte) ([]byte, string, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var coding string
+	switch compression {
+	case GzipCompression:
+		w, coding = gzip.NewWriter(&buf), "gzip"
+	case DeflateCompression:
+		w, coding = zlib.NewWriter(&buf), "deflate"
+	default:
+		return body, "", nil
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, "", err
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), coding, nil
+}
+
+// readResponse reads the body of the response from r, decoding it according to its Content-Encoding
+func (c *Client) readResponse(resp *http.Response, r io.Reader) ([]byte, error) {
+	var body io.Reader
+	switch coding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))); coding {
+	case "", "identity":
+		body = r
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("gzip response: %w", err)
+		}
+		defer zr.Close()
+		body = zr
+	case "deflate":
+		// The deflate coding is the zlib format, but some servers send raw deflate data
+		br := bufio.NewReader(r)
+		header, err := br.Peek(2)
+		if err == nil && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 && header[0]&0x0F == 8 {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				return nil, fmt.Errorf("deflate response: %w", err)
+			}
+			defer zr.Close()
+			body = zr
+		} else {
+			fr := flate.NewReader(br)
+			defer fr.Close()
+			body = fr
+		}
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %v", coding)
+	}
+	return c.limits.readBody(body, resp.ContentLength)
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
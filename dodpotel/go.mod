@@ -0,0 +1,22 @@
+module github.com/kvark128/dodp/dodpotel
+
+go 1.21
+
+require (
+	github.com/kvark128/dodp v0.0.0
+	go.opentelemetry.io/otel v1.21.0
+	go.opentelemetry.io/otel/metric v1.21.0
+	go.opentelemetry.io/otel/sdk v1.21.0
+	go.opentelemetry.io/otel/sdk/metric v1.21.0
+	go.opentelemetry.io/otel/trace v1.21.0
+)
+
+require (
+	github.com/go-logr/logr v1.3.0 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	golang.org/x/sys v0.14.0 // indirect
+)
+
+// The module is developed together with the client and tested against the client in the same tree.
+// A release of the module must require a tagged release of the client and drop this directive.
+replace github.com/kvark128/dodp => ../
@@ -0,0 +1,78 @@
+// Package dodpotel provides OpenTelemetry instrumentation for the DAISY Online client.
+//
+// It is a separate module, so the client does not depend on OpenTelemetry.
+// Its go.mod replaces the client with the parent directory, so it is always built against the client of the same tree.
+// A workspace in the root of the repository allows testing both modules at once: go work init . ./dodpotel
+package dodpotel
+
+import (
+	"context"
+
+	"github.com/kvark128/dodp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const scopeName = "github.com/kvark128/dodp"
+
+// Instrumentation creates a client span for each SOAP request and records the request metrics:
+// dodp.client.requests, dodp.client.errors, dodp.client.duration and dodp.client.response.size.
+type Instrumentation struct {
+	tracer       trace.Tracer
+	requests     metric.Int64Counter
+	errors       metric.Int64Counter
+	duration     metric.Float64Histogram
+	responseSize metric.Int64Histogram
+}
+
+// New creates the instrumentation using the specified providers.
+// For tests the providers of the OpenTelemetry SDK with in-memory exporters can be passed.
+func New(tp trace.TracerProvider, mp metric.MeterProvider) (*Instrumentation, error) {
+	meter := mp.Meter(scopeName)
+	i := &Instrumentation{tracer: tp.Tracer(scopeName)}
+	var err error
+	if i.requests, err = meter.Int64Counter("dodp.client.requests", metric.WithDescription("Number of SOAP requests")); err != nil {
+		return nil, err
+	}
+	if i.errors, err = meter.Int64Counter("dodp.client.errors", metric.WithDescription("Number of failed SOAP requests")); err != nil {
+		return nil, err
+	}
+	if i.duration, err = meter.Float64Histogram("dodp.client.duration", metric.WithDescription("Duration of SOAP requests"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if i.responseSize, err = meter.Int64Histogram("dodp.client.response.size", metric.WithDescription("Size of SOAP responses"), metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+func (i *Instrumentation) StartRequest(ctx context.Context, ex *dodp.Exchange) context.Context {
+	attrs := []attribute.KeyValue{attribute.String("dodp.action", ex.Action)}
+	if ex.ContentID != "" {
+		attrs = append(attrs, attribute.String("dodp.content_id", ex.ContentID))
+	}
+	ctx, _ = i.tracer.Start(ctx, "dodp."+ex.Action, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx
+}
+
+func (i *Instrumentation) EndRequest(ctx context.Context, ex *dodp.Exchange, err error) {
+	span := trace.SpanFromContext(ctx)
+	action := attribute.String("dodp.action", ex.Action)
+	if ex.StatusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", ex.StatusCode))
+	}
+
+	i.requests.Add(ctx, 1, metric.WithAttributes(action))
+	if err != nil {
+		class := attribute.String("dodp.error", dodp.ErrorClass(err))
+		i.errors.Add(ctx, 1, metric.WithAttributes(action, class))
+		span.SetAttributes(class)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	i.duration.Record(ctx, ex.Duration.Seconds(), metric.WithAttributes(action))
+	i.responseSize.Record(ctx, int64(len(ex.Response)), metric.WithAttributes(action))
+	span.End()
+}
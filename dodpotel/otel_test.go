@@ -0,0 +1,98 @@
+package dodpotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kvark128/dodp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInstrumentation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	i, err := New(tp, mp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	succeeded := &dodp.Exchange{Action: "getContentMetadata", ContentID: "book1", StatusCode: 200, Response: make([]byte, 100), Duration: time.Second}
+	i.EndRequest(i.StartRequest(context.Background(), succeeded), succeeded, nil)
+	failed := &dodp.Exchange{Action: "getContentMetadata", StatusCode: 500, Duration: 2 * time.Second}
+	i.EndRequest(i.StartRequest(context.Background(), failed), failed, errors.New("boom"))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %v spans, want 2", len(spans))
+	}
+	if spans[0].Name() != "dodp.getContentMetadata" || spans[0].SpanKind() != trace.SpanKindClient {
+		t.Errorf("unexpected span %v of kind %v", spans[0].Name(), spans[0].SpanKind())
+	}
+	if !hasAttribute(spans[0].Attributes(), attribute.String("dodp.content_id", "book1")) {
+		t.Errorf("content ID is missing in %v", spans[0].Attributes())
+	}
+	if !hasAttribute(spans[0].Attributes(), attribute.Int("http.response.status_code", 200)) {
+		t.Errorf("status code is missing in %v", spans[0].Attributes())
+	}
+	if spans[1].Status().Code != codes.Error || !hasAttribute(spans[1].Attributes(), attribute.String("dodp.error", "other")) {
+		t.Errorf("failed span has status %v and attributes %v", spans[1].Status(), spans[1].Attributes())
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	if got := sumValue(t, metrics["dodp.client.requests"]); got != 2 {
+		t.Errorf("got %v requests, want 2", got)
+	}
+	if got := sumValue(t, metrics["dodp.client.errors"]); got != 1 {
+		t.Errorf("got %v errors, want 1", got)
+	}
+	duration, ok := metrics["dodp.client.duration"].(metricdata.Histogram[float64])
+	if !ok || len(duration.DataPoints) != 1 || duration.DataPoints[0].Count != 2 || duration.DataPoints[0].Sum != 3 {
+		t.Errorf("unexpected duration histogram %+v", metrics["dodp.client.duration"])
+	}
+	size, ok := metrics["dodp.client.response.size"].(metricdata.Histogram[int64])
+	if !ok || len(size.DataPoints) != 1 || size.DataPoints[0].Sum != 100 {
+		t.Errorf("unexpected response size histogram %+v", metrics["dodp.client.response.size"])
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
+
+func sumValue(t *testing.T, data metricdata.Aggregation) int64 {
+	t.Helper()
+	sum, ok := data.(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("unexpected aggregation %T", data)
+	}
+	var total int64
+	for _, dp := range sum.DataPoints {
+		total += dp.Value
+	}
+	return total
+}
@@ -0,0 +1,142 @@
+package dodp
+
+import (
+	"strings"
+)
+
+// The types of the protocol values restricted by the specification.
+// Unknown values received from a Service are kept as is, so the Valid methods can be used to check them.
+// Surrounding whitespace is removed when the values are unmarshalled.
+
+// Input type of an answer to a question: TEXT_NUMERIC, TEXT_ALPHANUMERIC or AUDIO.
+type InputType string
+
+func (t InputType) String() string { return string(t) }
+
+func (t InputType) Valid() bool {
+	return t == TEXT_NUMERIC || t == TEXT_ALPHANUMERIC || t == AUDIO
+}
+
+func (t *InputType) UnmarshalText(text []byte) error {
+	*t = InputType(strings.TrimSpace(string(text)))
+	return nil
+}
+
+// Content Selection Method supported by the Service.
+type SelectionMethod string
+
+const (
+	OutOfBand SelectionMethod = "OUT_OF_BAND"
+	Browse    SelectionMethod = "BROWSE"
+)
+
+func (m SelectionMethod) String() string { return string(m) }
+
+func (m SelectionMethod) Valid() bool {
+	return m == OutOfBand || m == Browse
+}
+
+func (m *SelectionMethod) UnmarshalText(text []byte) error {
+	*m = SelectionMethod(strings.TrimSpace(string(text)))
+	return nil
+}
+
+// Category of the Content item.
+type Category string
+
+const (
+	CategoryBook      Category = "BOOK"
+	CategoryMagazine  Category = "MAGAZINE"
+	CategoryNewspaper Category = "NEWSPAPER"
+	CategoryOther     Category = "OTHER"
+)
+
+func (c Category) String() string { return string(c) }
+
+func (c Category) Valid() bool {
+	return c == CategoryBook || c == CategoryMagazine || c == CategoryNewspaper || c == CategoryOther
+}
+
+func (c *Category) UnmarshalText(text []byte) error {
+	*c = Category(strings.TrimSpace(string(text)))
+	return nil
+}
+
+// Type of the Service announcement.
+type AnnouncementType string
+
+const (
+	AnnouncementWarning     AnnouncementType = "WARNING"
+	AnnouncementError       AnnouncementType = "ERROR"
+	AnnouncementInformation AnnouncementType = "INFORMATION"
+	AnnouncementSystem      AnnouncementType = "SYSTEM"
+)
+
+func (t AnnouncementType) String() string { return string(t) }
+
+func (t AnnouncementType) Valid() bool {
+	return t == AnnouncementWarning || t == AnnouncementError || t == AnnouncementInformation || t == AnnouncementSystem
+}
+
+func (t *AnnouncementType) UnmarshalText(text []byte) error {
+	*t = AnnouncementType(strings.TrimSpace(string(text)))
+	return nil
+}
+
+// Text direction of the label.
+type Direction string
+
+const (
+	LTR Direction = "ltr"
+	RTL Direction = "rtl"
+)
+
+func (d Direction) String() string { return string(d) }
+
+func (d Direction) Valid() bool {
+	return d == LTR || d == RTL
+}
+
+func (d *Direction) UnmarshalText(text []byte) error {
+	*d = Direction(strings.TrimSpace(string(text)))
+	return nil
+}
+
+// Format of the Content, e.g. ANSI/NISO Z39.86-2005.
+// Services are not consistent in the case of the names, so Is should be used for comparisons.
+type ContentFormat string
+
+const (
+	Daisy202 ContentFormat = "DAISY 2.02"
+	Z3986    ContentFormat = "ANSI/NISO Z39.86-2005"
+)
+
+func (f ContentFormat) String() string { return string(f) }
+
+func (f ContentFormat) Valid() bool {
+	return f.Is(Daisy202) || f.Is(Z3986)
+}
+
+// Is reports whether the format has the same name as other, ignoring case.
+func (f ContentFormat) Is(other ContentFormat) bool {
+	return strings.EqualFold(string(f), string(other))
+}
+
+func (f *ContentFormat) UnmarshalText(text []byte) error {
+	*f = ContentFormat(strings.TrimSpace(string(text)))
+	return nil
+}
+
+// Content protection format, e.g. PDTB2.
+type ProtectionFormat string
+
+func (f ProtectionFormat) String() string { return string(f) }
+
+func (f ProtectionFormat) Valid() bool {
+	return f == PDTB2
+}
+
+func (f *ProtectionFormat) UnmarshalText(text []byte) error {
+	*f = ProtectionFormat(strings.TrimSpace(string(text)))
+	return nil
+}
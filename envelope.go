@@ -0,0 +1,160 @@
+package dodp
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ErrDryRun is returned by the operations of a client in the dry-run mode.
+var ErrDryRun = errors.New("dry run")
+
+// MarshalEnvelope returns the SOAP envelope with the specified content and header blocks exactly as a client with this profile sends it.
+// The content is a request of a custom operation, as for Client.Call. The envelopes of the standard operations are returned by Client.RequestEnvelope.
+func (p Profile) MarshalEnvelope(content any, blocks ...HeaderBlock) ([]byte, error) {
+	return p.marshal(blocks, content)
+}
+
+type envelopeCaptureKey struct{}
+
+// Receives the request envelope instead of sending it
+type envelopeCapture struct {
+	envelope []byte
+}
+
+var errEnvelopeCaptured = errors.New("request envelope captured")
+
+// RequestEnvelope returns the SOAP envelope that the client sends for the operation performed by op, without sending it.
+// The op function must perform one operation of the client with the passed context, e.g.
+//
+//	env, err := c.RequestEnvelope(ctx, func(ctx context.Context) error {
+//		_, err := c.GetContentListContext(ctx, dodp.Issued, 0, -1)
+//		return err
+//	})
+//
+// The envelope is built by the profile of the client and includes its header blocks and those of ctx.
+// Unlike the requests recorded in the dry-run mode, the password of the logOn operation is not redacted.
+func (c *Client) RequestEnvelope(ctx context.Context, op func(ctx context.Context) error) ([]byte, error) {
+	capture := &envelopeCapture{}
+	err := op(context.WithValue(ctx, envelopeCaptureKey{}, capture))
+	if capture.envelope != nil {
+		return capture.envelope, nil
+	}
+	if err == nil {
+		err = errors.New("no request made by the operation")
+	}
+	return nil, err
+}
+
+// captureEnvelope stores the envelope if the request is made by RequestEnvelope
+func captureEnvelope(ctx context.Context, envelope []byte) bool {
+	capture, ok := ctx.Value(envelopeCaptureKey{}).(*envelopeCapture)
+	if ok {
+		capture.envelope = envelope
+	}
+	return ok
+}
+
+// UnmarshalEnvelope decodes the result of an operation from a saved response envelope.
+// The first element of the Body is the response of the operation, e.g. getContentListResponse, and its first child is decoded into v, e.g. *ContentList.
+// For the operations returning a boolean result v can be *bool.
+// If the Body contains a SOAP fault, it is returned as a *Fault error.
+func UnmarshalEnvelope(data []byte, v any) error {
+	data, err := toUTF8(data, "")
+	if err != nil {
+		return err
+	}
+
+	var env envelope
+	env.Body.Content = &operationResult{v: v}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = passCharsetReader
+	if err := dec.Decode(&env); err != nil {
+		return err
+	}
+	if env.XMLName.Local != "Envelope" || !isEnvelopeNS(env.XMLName.Space) {
+		return fmt.Errorf("unexpected root element %v", env.XMLName.Local)
+	}
+	if env.Body.Fault != nil {
+		return env.Body.Fault
+	}
+	return nil
+}
+
+// operationResult decodes the first child element of the operation response into v
+type operationResult struct {
+	v any
+}
+
+func (r *operationResult) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if err := d.DecodeElement(r.v, &t); err != nil {
+				return err
+			}
+			return d.Skip()
+		case xml.EndElement:
+			return fmt.Errorf("%v has no result", start.Name.Local)
+		}
+	}
+}
+
+// RecordedRequest is a request recorded by a client in the dry-run mode.
+type RecordedRequest struct {
+	Action string
+	// HTTP headers of the request
+	Header http.Header
+	// The request envelope. For the logOn operation the password is redacted, unless WithoutPasswordRedaction is used.
+	Envelope []byte
+}
+
+// Recorder collects the requests of a client in the dry-run mode. It is safe for concurrent use.
+type Recorder struct {
+	mu       sync.Mutex
+	requests []RecordedRequest
+}
+
+// Requests returns the recorded requests in the order they were made.
+func (r *Recorder) Requests() []RecordedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedRequest(nil), r.requests...)
+}
+
+// Reset removes all recorded requests.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = nil
+}
+
+func (r *Recorder) record(req RecordedRequest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+}
+
+// WithDryRun makes the client record the requests with the recorder instead of sending them.
+// All operations fail with ErrDryRun.
+func WithDryRun(recorder *Recorder) Option {
+	return func(c *Client) error {
+		if recorder == nil {
+			return errors.New("nil recorder")
+		}
+		c.dryRun = recorder
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package dodp
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestEnvelope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request sent to the Service")
+	}))
+	defer server.Close()
+
+	token := HeaderBlock{XMLName: xml.Name{Space: "urn:example", Local: "token"}, Content: "abc"}
+	c, err := NewClientWithOptions(server.URL, WithHeaderBlocks(token))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		op   func(ctx context.Context) error
+		want string
+	}{
+		{
+			name: "getContentList",
+			ctx:  context.Background(),
+			op: func(ctx context.Context) error {
+				_, err := c.GetContentListContext(ctx, Issued, 0, -1)
+				return err
+			},
+			want: `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Header><token xmlns="urn:example">abc</token></Header><Body><getContentList xmlns="http://www.daisy.org/ns/daisy-online/"><id>issued</id><firstItem>0</firstItem><lastItem>-1</lastItem></getContentList></Body></Envelope>`,
+		},
+		{
+			name: "logOn with context header blocks",
+			ctx:  ContextWithHeaderBlocks(context.Background(), HeaderBlock{XMLName: xml.Name{Space: "urn:example", Local: "trace"}, Content: "1"}),
+			op: func(ctx context.Context) error {
+				_, err := c.LogOnContext(ctx, "user", "secret")
+				return err
+			},
+			want: `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Header><token xmlns="urn:example">abc</token><trace xmlns="urn:example">1</trace></Header><Body><logOn xmlns="http://www.daisy.org/ns/daisy-online/"><username>user</username><password>secret</password></logOn></Body></Envelope>`,
+		},
+	}
+	for _, tt := range tests {
+		env, err := c.RequestEnvelope(tt.ctx, tt.op)
+		if err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+			continue
+		}
+		if want := xml.Header + tt.want; string(env) != want {
+			t.Errorf("%v: got\n%s\nwant\n%s", tt.name, env, want)
+		}
+	}
+
+	if _, err := c.RequestEnvelope(context.Background(), func(ctx context.Context) error { return nil }); err == nil {
+		t.Error("expected an error for an operation without a request")
+	}
+}
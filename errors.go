@@ -0,0 +1,38 @@
+package dodp
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Maximum number of bytes of the response body stored in HTTPError
+const maxErrorBodySize = 512
+
+// HTTPError is returned when the response of a service is not a valid SOAP envelope.
+// This usually means that the request did not reach the service, e.g. a proxy error page, a captive portal or an empty body.
+type HTTPError struct {
+	Operation   string
+	StatusCode  int
+	Status      string
+	ContentType string
+	Header      http.Header
+	// The beginning of the response body, at most 512 bytes
+	Body string
+	// The error that occurred when decoding the response, if any
+	Err error
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("unexpected response to %v: %v", e.Operation, e.Status)
+	if e.ContentType != "" {
+		msg += fmt.Sprintf(" (%v)", e.ContentType)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,50 @@
+package dodp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		httpErr     bool
+	}{
+		{"proxy error page", http.StatusBadGateway, "text/html", "<html><body>Bad Gateway</body></html>", true},
+		{"empty body", http.StatusOK, "text/xml", "", true},
+		{"malformed XML", http.StatusOK, "text/xml", `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`, true},
+		{"not an envelope", http.StatusOK, "text/xml", `<getContentListResponse xmlns="http://www.daisy.org/ns/daisy-online/"/>`, true},
+		{"envelope with unexpected content", http.StatusOK, "text/xml", `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><getBookmarksResponse xmlns="http://www.daisy.org/ns/daisy-online/"/></s:Body></s:Envelope>`, false},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", tt.contentType)
+			w.WriteHeader(tt.status)
+			fmt.Fprint(w, tt.body)
+		}))
+		c, err := NewClientWithOptions(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.GetContentList(Issued, 0, -1)
+		server.Close()
+		if err == nil {
+			t.Errorf("%v: expected an error", tt.name)
+			continue
+		}
+		var httpErr *HTTPError
+		if got := errors.As(err, &httpErr); got != tt.httpErr {
+			t.Errorf("%v: got %v, HTTPError %v, want %v", tt.name, err, got, tt.httpErr)
+			continue
+		}
+		if httpErr != nil && (httpErr.StatusCode != tt.status || httpErr.Body != tt.body || httpErr.Operation != "getContentList") {
+			t.Errorf("%v: unexpected %+v", tt.name, httpErr)
+		}
+	}
+}
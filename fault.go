@@ -0,0 +1,117 @@
+package dodp
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+)
+
+// Faults defined by DAISY Online Delivery Protocol v1.
+// They can be checked with errors.Is on any error returned by the Client methods.
+var (
+	ErrInvalidOperation      = errors.New("invalidOperation")
+	ErrInvalidParameter      = errors.New("invalidParameter")
+	ErrInternalServerError   = errors.New("internalServerError")
+	ErrNoActiveSession       = errors.New("noActiveSession")
+	ErrOperationNotSupported = errors.New("operationNotSupported")
+)
+
+var faultKinds = map[string]error{
+	"invalidOperation":      ErrInvalidOperation,
+	"invalidParameter":      ErrInvalidParameter,
+	"internalServerError":   ErrInternalServerError,
+	"noActiveSession":       ErrNoActiveSession,
+	"operationNotSupported": ErrOperationNotSupported,
+}
+
+// SOAP fault.
+// SOAP 1.2 faults are converted to this form: the Code value becomes the faultcode, the Reason text becomes the faultstring and the Node becomes the faultactor.
+type Fault struct {
+	XMLName     xml.Name `xml:"Fault"`
+	Faultcode   string   `xml:"faultcode"`
+	Faultstring string   `xml:"faultstring"`
+	Faultactor  string   `xml:"faultactor"`
+	Detail      Detail   `xml:"detail"`
+	// Values of the nested Subcode elements of a SOAP 1.2 fault
+	Subcodes []string `xml:"-"`
+}
+
+// Application specific error information of the SOAP fault.
+// DODP services put here an element whose name identifies the fault, e.g. invalidParameterFault.
+type Detail struct {
+	Items []DetailItem `xml:",any"`
+}
+
+type DetailItem struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+}
+
+func (f *Fault) Error() string {
+	if f.Faultstring != "" {
+		return f.Faultstring
+	}
+	if kind := f.Kind(); kind != nil {
+		return kind.Error()
+	}
+	return f.Faultcode
+}
+
+// Kind returns one of the DODP fault errors (ErrInvalidParameter, ErrNoActiveSession, etc.) that corresponds to the fault.
+// The fault is recognized by the elements of the detail and, if that fails, by the faultcode and subcodes. Nil is returned for unknown faults.
+func (f *Fault) Kind() error {
+	for _, item := range f.Detail.Items {
+		if kind := faultKind(item.XMLName.Local); kind != nil {
+			return kind
+		}
+	}
+	for _, code := range append([]string{f.Faultcode}, f.Subcodes...) {
+		if i := strings.LastIndexAny(code, ":."); i != -1 {
+			code = code[i+1:]
+		}
+		if kind := faultKind(code); kind != nil {
+			return kind
+		}
+	}
+	return nil
+}
+
+// Unwrap makes the DODP fault kind available to errors.Is.
+func (f *Fault) Unwrap() error {
+	return f.Kind()
+}
+
+func faultKind(name string) error {
+	return faultKinds[strings.TrimSuffix(strings.TrimSpace(name), "Fault")]
+}
+
+// SOAP 1.2 fault
+type soap12Fault struct {
+	Code   soap12Code `xml:"Code"`
+	Reason struct {
+		Text []string `xml:"Text"`
+	} `xml:"Reason"`
+	Node   string `xml:"Node"`
+	Detail Detail `xml:"Detail"`
+}
+
+type soap12Code struct {
+	Value   string      `xml:"Value"`
+	Subcode *soap12Code `xml:"Subcode"`
+}
+
+func (f *soap12Fault) toFault() *Fault {
+	fault := &Fault{
+		XMLName:    xml.Name{Space: soap12EnvelopeNS, Local: "Fault"},
+		Faultcode:  strings.TrimSpace(f.Code.Value),
+		Faultactor: f.Node,
+		Detail:     f.Detail,
+	}
+	if len(f.Reason.Text) != 0 {
+		fault.Faultstring = f.Reason.Text[0]
+	}
+	for code := f.Code.Subcode; code != nil; code = code.Subcode {
+		fault.Subcodes = append(fault.Subcodes, strings.TrimSpace(code.Value))
+	}
+	return fault
+}
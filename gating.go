@@ -0,0 +1,82 @@
+package dodp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// The optional operations of DAISY Online Delivery Protocol v1, as listed in SupportedOptionalOperations
+const (
+	SetBookmarksOperation         = "SET_BOOKMARKS"
+	GetBookmarksOperation         = "GET_BOOKMARKS"
+	ServiceAnnouncementsOperation = "SERVICE_ANNOUNCEMENTS"
+	DynamicMenusOperation         = "DYNAMIC_MENUS"
+	PDTB2KeyProvisionOperation    = "PDTB2_KEY_PROVISION"
+)
+
+// The optional operation each request belongs to
+var optionalOperations = map[string]string{
+	"setBookmarks":            SetBookmarksOperation,
+	"getBookmarks":            GetBookmarksOperation,
+	"getServiceAnnouncements": ServiceAnnouncementsOperation,
+	"markAnnouncementsAsRead": ServiceAnnouncementsOperation,
+	"getQuestions":            DynamicMenusOperation,
+	"getKeyExchangeObject":    PDTB2KeyProvisionOperation,
+}
+
+// Supports reports whether the Service supports the optional operation, e.g. GetBookmarksOperation.
+func (s *ServiceAttributes) Supports(op string) bool {
+	for _, o := range s.SupportedOptionalOperations.Operation {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// State of the optional operations gating
+type gating struct {
+	mu                sync.Mutex
+	enabled           bool
+	serviceAttributes *ServiceAttributes
+}
+
+// WithOperationGating makes the client refuse the optional operations that the Service does not support.
+// The check uses the service attributes received by the last getServiceAttributes operation, so before it all operations are allowed.
+// Refused operations fail with ErrOperationNotSupported without a request to the Service.
+func WithOperationGating() Option {
+	return func(c *Client) error {
+		c.gating.enabled = true
+		return nil
+	}
+}
+
+func (g *gating) setServiceAttributes(serviceAttributes *ServiceAttributes) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.enabled {
+		g.serviceAttributes = serviceAttributes
+	}
+}
+
+// check returns an error if the action belongs to an optional operation that the Service does not support
+func (g *gating) check(action string) error {
+	op, ok := optionalOperations[action]
+	if !ok {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.enabled || g.serviceAttributes == nil || g.serviceAttributes.Supports(op) {
+		return nil
+	}
+	return fmt.Errorf("%w: %v is not in the supported optional operations of the service", ErrOperationNotSupported, op)
+}
+
+func (c *Client) callGated(ctx context.Context, action string, call func() error) error {
+	if err := c.gating.check(action); err != nil {
+		return err
+	}
+	return call()
+}
@@ -1,3 +1,3 @@
 module github.com/kvark128/dodp
 
-go 1.20
+go 1.21
@@ -0,0 +1,128 @@
+package dodp
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// SOAP message header
+type header struct {
+	XMLName xml.Name      `xml:"Header"`
+	Blocks  []HeaderBlock `xml:",any"`
+}
+
+// HeaderBlock is a block of the SOAP Header, e.g. a session token or a service notice.
+type HeaderBlock struct {
+	// Name of the block. SOAP requires it to be qualified with a namespace.
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	// Raw XML content of the block
+	Content string `xml:",innerxml"`
+	// MustUnderstand corresponds to the mustUnderstand attribute of the block.
+	// For outgoing blocks the attribute is added automatically, for incoming ones the field is set from it.
+	MustUnderstand bool `xml:"-"`
+}
+
+// MustUnderstandError is returned when the response contains header blocks that must be understood, but the client does not understand them.
+type MustUnderstandError struct {
+	Action string
+	Blocks []xml.Name
+}
+
+func (e *MustUnderstandError) Error() string {
+	names := make([]string, len(e.Blocks))
+	for i, name := range e.Blocks {
+		names[i] = fmt.Sprintf("{%v}%v", name.Space, name.Local)
+	}
+	return "header blocks not understood: " + strings.Join(names, ", ")
+}
+
+// WithHeaderBlocks adds header blocks to each request of the client.
+func WithHeaderBlocks(blocks ...HeaderBlock) Option {
+	return func(c *Client) error {
+		c.headerBlocks = append(c.headerBlocks, blocks...)
+		return nil
+	}
+}
+
+// WithResponseHeaderHook sets a function called with the header blocks of each response that has a SOAP Header.
+// An error returned by the hook is returned by the operation.
+func WithResponseHeaderHook(hook func(action string, blocks []HeaderBlock) error) Option {
+	return func(c *Client) error {
+		c.headerHook = hook
+		return nil
+	}
+}
+
+// WithUnderstoodHeaders declares the header blocks that the client understands.
+// A response containing any other block with the mustUnderstand attribute fails with a MustUnderstandError.
+func WithUnderstoodHeaders(names ...xml.Name) Option {
+	return func(c *Client) error {
+		if c.understood == nil {
+			c.understood = make(map[xml.Name]bool)
+		}
+		for _, name := range names {
+			c.understood[name] = true
+		}
+		return nil
+	}
+}
+
+type headerBlocksKey struct{}
+
+// ContextWithHeaderBlocks returns a context that makes the operations called with it send the specified header blocks in addition to those of the client.
+func ContextWithHeaderBlocks(ctx context.Context, blocks ...HeaderBlock) context.Context {
+	prev, _ := ctx.Value(headerBlocksKey{}).([]HeaderBlock)
+	all := append(append([]HeaderBlock(nil), prev...), blocks...)
+	return context.WithValue(ctx, headerBlocksKey{}, all)
+}
+
+// requestHeader returns the header blocks of the client and the context
+func (c *Client) requestHeader(ctx context.Context) []HeaderBlock {
+	blocks, _ := ctx.Value(headerBlocksKey{}).([]HeaderBlock)
+	if len(blocks) == 0 {
+		return c.headerBlocks
+	}
+	return append(append([]HeaderBlock(nil), c.headerBlocks...), blocks...)
+}
+
+func (c *Client) processResponseHeader(action string, blocks []HeaderBlock) error {
+	var notUnderstood []xml.Name
+	for i := range blocks {
+		block := &blocks[i]
+		for _, attr := range block.Attrs {
+			if attr.Name.Local == "mustUnderstand" && isEnvelopeNS(attr.Name.Space) {
+				block.MustUnderstand = attr.Value == "1" || attr.Value == "true"
+			}
+		}
+		if block.MustUnderstand && !c.understood[block.XMLName] {
+			notUnderstood = append(notUnderstood, block.XMLName)
+		}
+	}
+	if len(notUnderstood) != 0 {
+		return &MustUnderstandError{Action: action, Blocks: notUnderstood}
+	}
+	if c.headerHook != nil {
+		return c.headerHook(action, blocks)
+	}
+	return nil
+}
+
+// headerBlocks returns copies of the blocks with the mustUnderstand attribute of the SOAP version of the profile
+func (p *Profile) headerBlocks(blocks []HeaderBlock) []HeaderBlock {
+	result := make([]HeaderBlock, len(blocks))
+	for i, block := range blocks {
+		block.Attrs = append([]xml.Attr(nil), block.Attrs...)
+		if block.MustUnderstand {
+			value := "1"
+			if p.SOAP12 {
+				value = "true"
+			}
+			block.Attrs = append(block.Attrs, xml.Attr{Name: xml.Name{Space: p.envelopeNS(), Local: "mustUnderstand"}, Value: value})
+		}
+		result[i] = block
+	}
+	return result
+}
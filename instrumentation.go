@@ -0,0 +1,150 @@
+package dodp
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Instrumentation receives measurements of each SOAP request of the client, e.g. for metrics and tracing.
+// Its methods may be called concurrently.
+type Instrumentation interface {
+	// StartRequest is called before the request is sent.
+	// The returned context is used for the request and passed to EndRequest, so it can carry a trace span.
+	StartRequest(ctx context.Context, ex *Exchange) context.Context
+	// EndRequest is called when the request is finished with its error, if any.
+	EndRequest(ctx context.Context, ex *Exchange, err error)
+}
+
+// WithInstrumentation sets the instrumentation of the client.
+func WithInstrumentation(instrumentation Instrumentation) Option {
+	return func(c *Client) error {
+		c.instrumentation = instrumentation
+		return nil
+	}
+}
+
+// ErrorClass returns a short name of the error suitable for labelling metrics.
+// DODP faults are named after their kind, e.g. invalidParameter. Other values are fault, http, canceled, transport and other.
+// An empty string is returned for a nil error.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	var fault *Fault
+	if errors.As(err, &fault) {
+		if kind := fault.Kind(); kind != nil {
+			return kind.Error()
+		}
+		return "fault"
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return "http"
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return "canceled"
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return "transport"
+	}
+	return "other"
+}
+
+// Upper bounds of the latency histogram buckets
+var latencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// ExpvarInstrumentation publishes the metrics of the client as an expvar map with the following keys:
+// requests, errors and responseBytes contain counters by operation name; errors are additionally broken down by ErrorClass in the form "operation:class";
+// latency contains a histogram for each operation, where the key of a bucket is its upper bound in milliseconds or +Inf.
+type ExpvarInstrumentation struct {
+	vars          *expvar.Map
+	requests      *expvar.Map
+	errors        *expvar.Map
+	responseBytes *expvar.Map
+	latency       *expvar.Map
+}
+
+// Guards the creation of the published and nested maps, which may be shared by several instrumentations reusing the same variable
+var expvarMu sync.Mutex
+
+// NewExpvarInstrumentation creates the instrumentation publishing the metrics under the specified name.
+// If a variable with this name is already published, it must be an *expvar.Map and it is reused.
+func NewExpvarInstrumentation(name string) (*ExpvarInstrumentation, error) {
+	expvarMu.Lock()
+	vars, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		if expvar.Get(name) != nil {
+			expvarMu.Unlock()
+			return nil, fmt.Errorf("expvar %v is not a map", name)
+		}
+		vars = expvar.NewMap(name)
+	}
+	expvarMu.Unlock()
+	return NewExpvarInstrumentationMap(vars), nil
+}
+
+// NewExpvarInstrumentationMap creates the instrumentation storing the metrics in the specified map without publishing it.
+// This is useful for inspecting the metrics in tests.
+func NewExpvarInstrumentationMap(vars *expvar.Map) *ExpvarInstrumentation {
+	return &ExpvarInstrumentation{
+		vars:          vars,
+		requests:      subMap(vars, "requests"),
+		errors:        subMap(vars, "errors"),
+		responseBytes: subMap(vars, "responseBytes"),
+		latency:       subMap(vars, "latency"),
+	}
+}
+
+func subMap(m *expvar.Map, key string) *expvar.Map {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if sub, ok := m.Get(key).(*expvar.Map); ok {
+		return sub
+	}
+	sub := new(expvar.Map).Init()
+	m.Set(key, sub)
+	return sub
+}
+
+// Map returns the map containing the metrics.
+func (e *ExpvarInstrumentation) Map() *expvar.Map {
+	return e.vars
+}
+
+func (e *ExpvarInstrumentation) StartRequest(ctx context.Context, ex *Exchange) context.Context {
+	return ctx
+}
+
+func (e *ExpvarInstrumentation) EndRequest(ctx context.Context, ex *Exchange, err error) {
+	e.requests.Add(ex.Action, 1)
+	if err != nil {
+		e.errors.Add(ex.Action+":"+ErrorClass(err), 1)
+	}
+	e.responseBytes.Add(ex.Action, int64(len(ex.Response)))
+
+	bucket := "+Inf"
+	for _, bound := range latencyBuckets {
+		if ex.Duration <= bound {
+			bucket = strconv.FormatInt(bound.Milliseconds(), 10)
+			break
+		}
+	}
+	subMap(e.latency, ex.Action).Add(bucket, 1)
+}
@@ -0,0 +1,104 @@
+package dodp
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExpvarInstrumentationMap(t *testing.T) {
+	vars := new(expvar.Map).Init()
+	e := NewExpvarInstrumentationMap(vars)
+	if e.Map() != vars {
+		t.Fatal("metrics are not stored in the specified map")
+	}
+
+	exchanges := []struct {
+		ex  *Exchange
+		err error
+	}{
+		{&Exchange{Action: "getContentList", Response: make([]byte, 100), Duration: 30 * time.Millisecond}, nil},
+		{&Exchange{Action: "getContentList", Response: make([]byte, 20), Duration: 40 * time.Millisecond}, &HTTPError{StatusCode: 503}},
+		{&Exchange{Action: "logOn", Duration: time.Minute}, context.Canceled},
+	}
+	for _, x := range exchanges {
+		ctx := e.StartRequest(context.Background(), x.ex)
+		e.EndRequest(ctx, x.ex, x.err)
+	}
+
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{[]string{"requests", "getContentList"}, "2"},
+		{[]string{"requests", "logOn"}, "1"},
+		{[]string{"errors", "getContentList:http"}, "1"},
+		{[]string{"errors", "logOn:canceled"}, "1"},
+		{[]string{"responseBytes", "getContentList"}, "120"},
+		{[]string{"responseBytes", "logOn"}, "0"},
+		{[]string{"latency", "getContentList", "50"}, "2"},
+		{[]string{"latency", "logOn", "+Inf"}, "1"},
+	}
+	for _, tt := range tests {
+		if got := lookupVar(vars, tt.path); got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func lookupVar(m *expvar.Map, path []string) string {
+	v := m.Get(path[0])
+	for _, key := range path[1:] {
+		sub, ok := v.(*expvar.Map)
+		if !ok {
+			return fmt.Sprintf("%v is not a map", v)
+		}
+		v = sub.Get(key)
+	}
+	if v == nil {
+		return "<nil>"
+	}
+	return v.String()
+}
+
+func TestExpvarInstrumentationShared(t *testing.T) {
+	const name = "dodpTestShared"
+	const instances, requests = 8, 50
+	// Published variables outlive the test, so the metrics of the previous runs are cleared
+	if vars, ok := expvar.Get(name).(*expvar.Map); ok {
+		vars.Init()
+	}
+	// The instances reuse the same published map and create its histograms concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < instances; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e, err := NewExpvarInstrumentation(name)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for j := 0; j < requests; j++ {
+				ex := &Exchange{Action: fmt.Sprintf("action%v", j%5), Duration: time.Millisecond}
+				e.EndRequest(e.StartRequest(context.Background(), ex), ex, nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	vars := expvar.Get(name).(*expvar.Map)
+	for j := 0; j < 5; j++ {
+		action := fmt.Sprintf("action%v", j)
+		want := fmt.Sprint(instances * requests / 5)
+		if got := lookupVar(vars, []string{"requests", action}); got != want {
+			t.Errorf("requests of %v: got %v, want %v", action, got, want)
+		}
+		if got := lookupVar(vars, []string{"latency", action, "10"}); got != want {
+			t.Errorf("latency of %v: got %v, want %v", action, got, want)
+		}
+	}
+}
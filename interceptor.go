@@ -0,0 +1,68 @@
+package dodp
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Exchange describes a single SOAP request to the Service and its response.
+// The response fields are filled in when the next function of the interceptor chain returns.
+type Exchange struct {
+	// Name of the operation, e.g. logOn
+	Action string
+	// Identifier of the Content item or content list the operation refers to, if any
+	ContentID string
+	// The marshalled request envelope. For the logOn operation the password is redacted, unless WithoutPasswordRedaction is used.
+	Request []byte
+	// Status code, header and decompressed body of the HTTP response. They are empty if the response was not received.
+	StatusCode int
+	Header     http.Header
+	Response   []byte
+	// Sizes of the request and response bodies as transferred, i.e. after compression
+	RequestWireSize  int64
+	ResponseWireSize int64
+	// Time when the request was sent and the time it took to receive the response
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Interceptor is called around each SOAP request of the client.
+// It must call next to continue the chain and should return its error, possibly wrapped.
+// Interceptors are intended for observation and cannot change the request sent to the Service.
+type Interceptor func(ctx context.Context, ex *Exchange, next func() error) error
+
+// WithInterceptors adds interceptors to the client. They are called in the order they are specified.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(c *Client) error {
+		c.interceptors = append(c.interceptors, interceptors...)
+		return nil
+	}
+}
+
+// WithoutPasswordRedaction makes the password of the logOn operation visible to interceptors.
+func WithoutPasswordRedaction() Option {
+	return func(c *Client) error {
+		c.disableRedaction = true
+		return nil
+	}
+}
+
+func (c *Client) intercept(ctx context.Context, ex *Exchange, last func() error) error {
+	next := last
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		interceptor, n := c.interceptors[i], next
+		next = func() error {
+			return interceptor(ctx, ex, n)
+		}
+	}
+	return next()
+}
+
+var passwordElement = regexp.MustCompile(`(<(?:[\w.-]+:)?password(?:\s[^>]*)?>)[^<]*(</(?:[\w.-]+:)?password>)`)
+
+// redactPassword returns a copy of the logOn request with the password replaced by asterisks
+func redactPassword(request []byte) []byte {
+	return passwordElement.ReplaceAll(request, []byte("${1}***${2}"))
+}
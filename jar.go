@@ -0,0 +1,139 @@
+package dodp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type persistentCookie struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// PersistentJar is a cookie jar that can be saved to a file and loaded back, so a DAISY Online session survives restarts of the Reading System.
+// Cookies without expiration (session cookies) are stored too, because most services keep the session in them.
+// Expired cookies are dropped when the jar is loaded or saved.
+type PersistentJar struct {
+	mu      sync.Mutex
+	path    string
+	jar     *cookiejar.Jar
+	cookies map[string]persistentCookie
+}
+
+// NewPersistentJar creates a jar stored in the specified file.
+// If the file exists, the cookies are loaded from it.
+// The jar is passed to the client with the WithCookieJar option.
+func NewPersistentJar(path string) (*PersistentJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	j := &PersistentJar{
+		path:    path,
+		jar:     jar,
+		cookies: make(map[string]persistentCookie),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored []persistentCookie
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("cookie jar file %v: %w", path, err)
+	}
+	for _, pc := range stored {
+		u, err := url.Parse(pc.URL)
+		if err != nil || pc.Cookie == nil {
+			continue
+		}
+		j.SetCookies(u, []*http.Cookie{pc.Cookie})
+	}
+	return j, nil
+}
+
+func (j *PersistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	for _, c := range cookies {
+		domain := c.Domain
+		if domain == "" {
+			domain = u.Hostname()
+		}
+		key := domain + ";" + c.Path + ";" + c.Name
+
+		cookie := *c
+		if cookie.MaxAge > 0 {
+			cookie.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+			cookie.MaxAge = 0
+		}
+		if cookie.MaxAge < 0 || (!cookie.Expires.IsZero() && !cookie.Expires.After(now)) {
+			delete(j.cookies, key)
+			continue
+		}
+		ref := url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}
+		j.cookies[key] = persistentCookie{URL: ref.String(), Cookie: &cookie}
+	}
+}
+
+func (j *PersistentJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar.Cookies(u)
+}
+
+// Save writes the unexpired cookies to the file of the jar.
+// The file is replaced atomically, so a crash during saving does not corrupt it.
+// The session cookies are secrets, so the file is created readable and writable only by its owner.
+func (j *PersistentJar) Save() error {
+	j.mu.Lock()
+	stored := make([]persistentCookie, 0, len(j.cookies))
+	now := time.Now()
+	for key, pc := range j.cookies {
+		if !pc.Cookie.Expires.IsZero() && !pc.Cookie.Expires.After(now) {
+			delete(j.cookies, key)
+			continue
+		}
+		stored = append(stored, pc)
+	}
+	j.mu.Unlock()
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	// The data must reach the disk before the rename, otherwise a crash may leave an empty file in place of the old one
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), j.path)
+}
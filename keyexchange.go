@@ -0,0 +1,96 @@
+package dodp
+
+import (
+	"encoding/xml"
+)
+
+// Namespaces of the PDTB2 key exchange object and the XML Encryption and XML Signature elements used in it
+const (
+	keyExchangeNS = "http://www.daisy.org/DRM/2005/KeyExchange"
+	xmlEncNS      = "http://www.w3.org/2001/04/xmlenc#"
+	xmlDSigNS     = "http://www.w3.org/2000/09/xmldsig#"
+)
+
+// The PDTB2 key exchange object.
+// It delivers to the Reading System the key pairs used to protect the Content, encrypted with the key of the Reading System.
+type KeyExchange struct {
+	XMLName xml.Name `xml:"http://www.daisy.org/DRM/2005/KeyExchange KeyExchange"`
+	Version string   `xml:"version,attr,omitempty"`
+	Issuer  string   `xml:"http://www.daisy.org/DRM/2005/KeyExchange Issuer"`
+	Keys    Keys
+}
+
+type Keys struct {
+	XMLName xml.Name  `xml:"http://www.daisy.org/DRM/2005/KeyExchange Keys"`
+	KeyPair []KeyPair `xml:"http://www.daisy.org/DRM/2005/KeyExchange KeyPair"`
+}
+
+// A key pair of the protected Content.
+// The private key is stored in EncryptedData encrypted with a session key, which is stored in EncryptedKey encrypted with the public key of the Reading System.
+type KeyPair struct {
+	XMLName       xml.Name        `xml:"http://www.daisy.org/DRM/2005/KeyExchange KeyPair"`
+	Name          string          `xml:"http://www.w3.org/2000/09/xmldsig# KeyName"`
+	KeyValue      *KeyValue       `xml:"http://www.w3.org/2000/09/xmldsig# KeyValue"`
+	EncryptedKey  []EncryptedKey  `xml:"http://www.w3.org/2001/04/xmlenc# EncryptedKey"`
+	EncryptedData []EncryptedData `xml:"http://www.w3.org/2001/04/xmlenc# EncryptedData"`
+}
+
+// Public RSA key in the XML Signature format
+type KeyValue struct {
+	XMLName     xml.Name     `xml:"http://www.w3.org/2000/09/xmldsig# KeyValue"`
+	RSAKeyValue *RSAKeyValue `xml:"http://www.w3.org/2000/09/xmldsig# RSAKeyValue"`
+}
+
+type RSAKeyValue struct {
+	XMLName  xml.Name `xml:"http://www.w3.org/2000/09/xmldsig# RSAKeyValue"`
+	Modulus  string   `xml:"http://www.w3.org/2000/09/xmldsig# Modulus"`
+	Exponent string   `xml:"http://www.w3.org/2000/09/xmldsig# Exponent"`
+}
+
+// An encrypted key in the XML Encryption format
+type EncryptedKey struct {
+	XMLName          xml.Name         `xml:"http://www.w3.org/2001/04/xmlenc# EncryptedKey"`
+	ID               string           `xml:"Id,attr,omitempty"`
+	Recipient        string           `xml:"Recipient,attr,omitempty"`
+	EncryptionMethod EncryptionMethod `xml:"http://www.w3.org/2001/04/xmlenc# EncryptionMethod"`
+	KeyInfo          *KeyInfo         `xml:"http://www.w3.org/2000/09/xmldsig# KeyInfo"`
+	CipherData       CipherData       `xml:"http://www.w3.org/2001/04/xmlenc# CipherData"`
+	CarriedKeyName   string           `xml:"http://www.w3.org/2001/04/xmlenc# CarriedKeyName,omitempty"`
+}
+
+// Encrypted data in the XML Encryption format
+type EncryptedData struct {
+	XMLName          xml.Name         `xml:"http://www.w3.org/2001/04/xmlenc# EncryptedData"`
+	ID               string           `xml:"Id,attr,omitempty"`
+	Type             string           `xml:"Type,attr,omitempty"`
+	MimeType         string           `xml:"MimeType,attr,omitempty"`
+	EncryptionMethod EncryptionMethod `xml:"http://www.w3.org/2001/04/xmlenc# EncryptionMethod"`
+	KeyInfo          *KeyInfo         `xml:"http://www.w3.org/2000/09/xmldsig# KeyInfo"`
+	CipherData       CipherData       `xml:"http://www.w3.org/2001/04/xmlenc# CipherData"`
+}
+
+type EncryptionMethod struct {
+	Algorithm string `xml:"Algorithm,attr"`
+}
+
+// Identifies the key needed to decrypt the data
+type KeyInfo struct {
+	KeyName         string           `xml:"http://www.w3.org/2000/09/xmldsig# KeyName,omitempty"`
+	RetrievalMethod *RetrievalMethod `xml:"http://www.w3.org/2000/09/xmldsig# RetrievalMethod"`
+	EncryptedKey    *EncryptedKey    `xml:"http://www.w3.org/2001/04/xmlenc# EncryptedKey"`
+}
+
+type RetrievalMethod struct {
+	URI  string `xml:"URI,attr"`
+	Type string `xml:"Type,attr,omitempty"`
+}
+
+// The encrypted value encoded with base64 or a reference to it
+type CipherData struct {
+	CipherValue     string           `xml:"http://www.w3.org/2001/04/xmlenc# CipherValue,omitempty"`
+	CipherReference *CipherReference `xml:"http://www.w3.org/2001/04/xmlenc# CipherReference"`
+}
+
+type CipherReference struct {
+	URI string `xml:"URI,attr"`
+}
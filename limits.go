@@ -0,0 +1,121 @@
+package dodp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrLimitExceeded is returned when a response exceeds one of the client limits.
+var ErrLimitExceeded = errors.New("response limit exceeded")
+
+// Limits restricts the size and complexity of the responses, protecting the Reading System from broken or hostile services.
+// Zero value of a field means no limit.
+type Limits struct {
+	// Maximum number of bytes of the response body
+	MaxResponseBytes int64
+	// Maximum nesting depth of the XML elements
+	MaxDepth int
+	// Maximum total number of the XML elements in a response
+	MaxElements int
+	// Maximum number of elements with the specified local names, e.g. {"contentItem": 1000}
+	MaxItems map[string]int
+}
+
+// DefaultLimits are used by the client unless the WithLimits option is specified.
+// They are far above the sizes of valid DODP responses.
+var DefaultLimits = Limits{
+	MaxResponseBytes: 64 << 20,
+	MaxDepth:         128,
+}
+
+// WithLimits sets the limits of the responses.
+func WithLimits(limits Limits) Option {
+	return func(c *Client) error {
+		if limits.MaxResponseBytes < 0 || limits.MaxDepth < 0 || limits.MaxElements < 0 {
+			return errors.New("negative limit")
+		}
+		for name, n := range limits.MaxItems {
+			if n < 0 {
+				return fmt.Errorf("negative limit MaxItems[%v]", name)
+			}
+		}
+		c.limits = limits
+		return nil
+	}
+}
+
+// LimitError describes the exceeded limit. It matches ErrLimitExceeded with errors.Is.
+type LimitError struct {
+	// Name of the limit, e.g. MaxResponseBytes or MaxItems[contentItem]
+	Limit string
+	Value int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v (%v = %v)", ErrLimitExceeded, e.Limit, e.Value)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// readBody reads the decompressed body of the response, failing if it is larger than allowed.
+// The limit applies to the decompressed size to protect against compression bombs.
+func (l *Limits) readBody(r io.Reader, contentLength int64) ([]byte, error) {
+	if l.MaxResponseBytes == 0 {
+		return io.ReadAll(r)
+	}
+	limitErr := &LimitError{Limit: "MaxResponseBytes", Value: l.MaxResponseBytes}
+	if contentLength > l.MaxResponseBytes {
+		return nil, limitErr
+	}
+	body, err := io.ReadAll(io.LimitReader(r, l.MaxResponseBytes+1))
+	if err != nil {
+		return body, err
+	}
+	if int64(len(body)) > l.MaxResponseBytes {
+		return body[:l.MaxResponseBytes], limitErr
+	}
+	return body, nil
+}
+
+// check scans the XML document for the violations of the structural limits before it is decoded
+func (l *Limits) check(doc []byte) error {
+	if l.MaxDepth == 0 && l.MaxElements == 0 && len(l.MaxItems) == 0 {
+		return nil
+	}
+
+	var depth, elements int
+	items := make(map[string]int)
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	dec.CharsetReader = passCharsetReader
+	for {
+		token, err := dec.RawToken()
+		if err != nil {
+			// Syntax errors are reported by the decoding of the envelope
+			return nil
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			elements++
+			if l.MaxDepth != 0 && depth > l.MaxDepth {
+				return &LimitError{Limit: "MaxDepth", Value: int64(l.MaxDepth)}
+			}
+			if l.MaxElements != 0 && elements > l.MaxElements {
+				return &LimitError{Limit: "MaxElements", Value: int64(l.MaxElements)}
+			}
+			if maxItems, ok := l.MaxItems[t.Name.Local]; ok {
+				items[t.Name.Local]++
+				if items[t.Name.Local] > maxItems {
+					return &LimitError{Limit: fmt.Sprintf("MaxItems[%v]", t.Name.Local), Value: int64(maxItems)}
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
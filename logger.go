@@ -0,0 +1,51 @@
+package dodp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// WithLogger makes the client log each SOAP request with the specified logger.
+// Successful requests of the operations that only retrieve information are logged at the debug level, other successful requests at the info level and failures at the warn level.
+// The logging is performed by an interceptor, so its position relative to other interceptors depends on the order of the options.
+func WithLogger(logger *slog.Logger) Option {
+	if logger == nil {
+		return func(c *Client) error {
+			return errors.New("nil logger")
+		}
+	}
+	return WithInterceptors(func(ctx context.Context, ex *Exchange, next func() error) error {
+		err := next()
+
+		attrs := []slog.Attr{
+			slog.String("action", ex.Action),
+			slog.Duration("duration", ex.Duration),
+		}
+		if ex.ContentID != "" {
+			attrs = append(attrs, slog.String("contentID", ex.ContentID))
+		}
+		if ex.StatusCode != 0 {
+			attrs = append(attrs, slog.Int("status", ex.StatusCode), slog.Int("responseSize", len(ex.Response)))
+		}
+
+		level := slog.LevelInfo
+		msg := "DODP operation"
+		if err != nil {
+			level = slog.LevelWarn
+			msg = "DODP operation failed"
+			var fault *Fault
+			if errors.As(err, &fault) {
+				attrs = append(attrs, slog.String("faultcode", fault.Faultcode))
+				if kind := fault.Kind(); kind != nil {
+					attrs = append(attrs, slog.String("fault", kind.Error()))
+				}
+			}
+			attrs = append(attrs, slog.String("error", err.Error()))
+		} else if idempotentOperations[ex.Action] || ex.Action == "getQuestions" {
+			level = slog.LevelDebug
+		}
+		logger.LogAttrs(ctx, level, msg, attrs...)
+		return err
+	})
+}
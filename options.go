@@ -0,0 +1,79 @@
+package dodp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Option configures a Client created by NewClientWithOptions.
+type Option func(*Client) error
+
+// WithContext sets the context used by the operations without the Context suffix.
+func WithContext(ctx context.Context) Option {
+	return func(c *Client) error {
+		if ctx == nil {
+			return errors.New("nil context")
+		}
+		c.ctx = ctx
+		return nil
+	}
+}
+
+// WithHTTPClient makes the client send requests using a copy of the specified HTTP client.
+// It replaces the settings of the options that modify the HTTP client, so it should be specified before them.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) error {
+		if httpClient == nil {
+			return errors.New("nil HTTP client")
+		}
+		hc := *httpClient
+		c.httpClient = &hc
+		return nil
+	}
+}
+
+// WithTransport sets the transport of the HTTP client, e.g. for proxies, custom CAs or client certificates.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) error {
+		c.httpClient.Transport = transport
+		return nil
+	}
+}
+
+// WithCookieJar sets the cookie jar that stores the session cookies.
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(c *Client) error {
+		c.httpClient.Jar = jar
+		return nil
+	}
+}
+
+// WithTimeout limits the execution time of each HTTP request. Zero timeout means no timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return errors.New("negative timeout")
+		}
+		c.httpClient.Timeout = timeout
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header of each request.
+func WithUserAgent(userAgent string) Option {
+	return WithHeader("User-Agent", userAgent)
+}
+
+// WithHeader adds a header to each request.
+// The headers required by the protocol, such as Content-Type and SOAPAction, cannot be overridden.
+func WithHeader(key, value string) Option {
+	return func(c *Client) error {
+		if key == "" {
+			return errors.New("empty header name")
+		}
+		c.header.Add(key, value)
+		return nil
+	}
+}
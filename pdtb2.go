@@ -0,0 +1,273 @@
+package dodp
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Algorithms of XML Encryption used by PDTB2
+const (
+	AlgorithmRSA15     = "http://www.w3.org/2001/04/xmlenc#rsa-1_5"
+	AlgorithmRSAOAEP   = "http://www.w3.org/2001/04/xmlenc#rsa-oaep-mgf1p"
+	AlgorithmAES128CBC = "http://www.w3.org/2001/04/xmlenc#aes128-cbc"
+	AlgorithmAES192CBC = "http://www.w3.org/2001/04/xmlenc#aes192-cbc"
+	AlgorithmAES256CBC = "http://www.w3.org/2001/04/xmlenc#aes256-cbc"
+)
+
+// ErrNotProtected is returned when a resource has no encryption information.
+var ErrNotProtected = errors.New("resource is not protected")
+
+// PDTB2Keys holds the key pairs of the protected Content unwrapped from a key exchange object.
+type PDTB2Keys struct {
+	keys map[string]*rsa.PrivateKey
+}
+
+// NewPDTB2Keys unwraps the key pairs of the key exchange object with the private key of the Reading System.
+// The key exchange object must be requested with the name of the same key.
+func NewPDTB2Keys(kx *KeyExchange, readingSystemKey *rsa.PrivateKey) (*PDTB2Keys, error) {
+	k := &PDTB2Keys{keys: make(map[string]*rsa.PrivateKey)}
+	for i, pair := range kx.Keys.KeyPair {
+		if len(pair.EncryptedKey) == 0 || len(pair.EncryptedData) == 0 {
+			return nil, fmt.Errorf("key pair %v: missing encrypted key or data", i)
+		}
+		sessionKey, err := decryptKey(&pair.EncryptedKey[0], readingSystemKey)
+		if err != nil {
+			return nil, fmt.Errorf("key pair %v: %w", i, err)
+		}
+		ed := &pair.EncryptedData[0]
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(ed.CipherData.CipherValue))
+		if err != nil {
+			return nil, fmt.Errorf("key pair %v: %w", i, err)
+		}
+		der, err := io.ReadAll(newCBCReader(bytes.NewReader(data), sessionKey, ed.EncryptionMethod.Algorithm))
+		if err != nil {
+			return nil, fmt.Errorf("key pair %v: %w", i, err)
+		}
+		privateKey, err := parseRSAPrivateKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("key pair %v: %w", i, err)
+		}
+
+		name := pair.Name
+		if name == "" {
+			name = pair.EncryptedKey[0].CarriedKeyName
+		}
+		k.keys[name] = privateKey
+	}
+	return k, nil
+}
+
+// DecryptKey unwraps a content key encrypted with one of the key pairs.
+// The key pair is chosen by the KeyName of the KeyInfo. If it is absent, the only key pair is used.
+func (k *PDTB2Keys) DecryptKey(ek *EncryptedKey) ([]byte, error) {
+	name := ""
+	if ek.KeyInfo != nil {
+		name = ek.KeyInfo.KeyName
+	}
+	privateKey, ok := k.keys[name]
+	if !ok && name == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			privateKey, ok = key, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key pair: %v", name)
+	}
+	return decryptKey(ek, privateKey)
+}
+
+// NewReader returns a reader decrypting the data described by ed, read from r.
+// The content key is taken from the EncryptedKey in the KeyInfo of ed.
+// The cipher data is read from r, which is usually the body of the resource referenced by the CipherReference.
+func (k *PDTB2Keys) NewReader(ed *EncryptedData, r io.Reader) (io.Reader, error) {
+	if ed.KeyInfo == nil || ed.KeyInfo.EncryptedKey == nil {
+		return nil, errors.New("encrypted data without encrypted key")
+	}
+	key, err := k.DecryptKey(ed.KeyInfo.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAESKey(key, ed.EncryptionMethod.Algorithm); err != nil {
+		return nil, err
+	}
+	return newCBCReader(r, key, ed.EncryptionMethod.Algorithm), nil
+}
+
+// Encryption lists the encrypted resources of the protected Content.
+// It is the XML Encryption document distributed with the Content.
+type Encryption struct {
+	XMLName       xml.Name
+	EncryptedData []EncryptedData `xml:"http://www.w3.org/2001/04/xmlenc# EncryptedData"`
+}
+
+// ParseEncryption reads the list of the encrypted resources.
+func ParseEncryption(r io.Reader) (*Encryption, error) {
+	e := &Encryption{}
+	if err := xml.NewDecoder(r).Decode(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// OpenResource returns a reader decrypting the body of the resource.
+// The resource is matched by its localURI against the CipherReference URIs of the encryption document.
+// ErrNotProtected is returned if the resource is not listed there, and then the body can be used as is.
+func (k *PDTB2Keys) OpenResource(enc *Encryption, res Resource, body io.Reader) (io.Reader, error) {
+	for i := range enc.EncryptedData {
+		ed := &enc.EncryptedData[i]
+		if ed.CipherData.CipherReference != nil && ed.CipherData.CipherReference.URI == res.LocalURI {
+			return k.NewReader(ed, body)
+		}
+	}
+	return nil, ErrNotProtected
+}
+
+func decryptKey(ek *EncryptedKey, privateKey *rsa.PrivateKey) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(ek.CipherData.CipherValue))
+	if err != nil {
+		return nil, err
+	}
+	switch ek.EncryptionMethod.Algorithm {
+	case AlgorithmRSA15:
+		return rsa.DecryptPKCS1v15(rand.Reader, privateKey, data)
+	case AlgorithmRSAOAEP:
+		return rsa.DecryptOAEP(sha1.New(), rand.Reader, privateKey, data, nil)
+	}
+	return nil, fmt.Errorf("unsupported key encryption algorithm: %v", ek.EncryptionMethod.Algorithm)
+}
+
+func parseRSAPrivateKey(der []byte) (*rsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+	return rsaKey, nil
+}
+
+func checkAESKey(key []byte, algorithm string) error {
+	sizes := map[string]int{
+		AlgorithmAES128CBC: 16,
+		AlgorithmAES192CBC: 24,
+		AlgorithmAES256CBC: 32,
+	}
+	size, ok := sizes[algorithm]
+	if !ok {
+		return fmt.Errorf("unsupported data encryption algorithm: %v", algorithm)
+	}
+	if len(key) != size {
+		return fmt.Errorf("invalid key size %v for %v", len(key), algorithm)
+	}
+	return nil
+}
+
+// cbcReader decrypts a stream in the XML Encryption block cipher format: the IV followed by the cipher text in CBC mode.
+// The last byte of the plain text is the length of the padding.
+type cbcReader struct {
+	r         io.Reader
+	key       []byte
+	algorithm string
+	mode      cipher.BlockMode
+	// Decrypted bytes that are not yet returned. The last block is held back until the end of the stream to remove the padding.
+	plain []byte
+	next  []byte
+	err   error
+}
+
+func newCBCReader(r io.Reader, key []byte, algorithm string) *cbcReader {
+	return &cbcReader{r: r, key: key, algorithm: algorithm}
+}
+
+func (c *cbcReader) Read(p []byte) (int, error) {
+	for len(c.plain) == 0 && c.err == nil {
+		c.fill()
+	}
+	if len(c.plain) != 0 {
+		n := copy(p, c.plain)
+		c.plain = c.plain[n:]
+		return n, nil
+	}
+	return 0, c.err
+}
+
+func (c *cbcReader) fill() {
+	if c.mode == nil {
+		if err := checkAESKey(c.key, c.algorithm); err != nil {
+			c.err = err
+			return
+		}
+		block, err := aes.NewCipher(c.key)
+		if err != nil {
+			c.err = err
+			return
+		}
+		iv := make([]byte, aes.BlockSize)
+		if _, err := io.ReadFull(c.r, iv); err != nil {
+			c.err = fmt.Errorf("reading IV: %w", err)
+			return
+		}
+		c.mode = cipher.NewCBCDecrypter(block, iv)
+	}
+
+	buf := make([]byte, 32*aes.BlockSize)
+	n, err := io.ReadAtLeast(c.r, buf, aes.BlockSize)
+	// The stream must consist of whole blocks, so the remainder is read to complete the last one
+	if rem := n % aes.BlockSize; rem != 0 && err == nil {
+		var m int
+		m, err = io.ReadFull(c.r, buf[n:n+aes.BlockSize-rem])
+		n += m
+	}
+	if n%aes.BlockSize != 0 {
+		c.err = errors.New("cipher text is not a multiple of the block size")
+		return
+	}
+
+	data := buf[:n]
+	c.mode.CryptBlocks(data, data)
+	c.plain = append(c.next, data...)
+	c.next = nil
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		c.finish()
+		return
+	}
+	if err != nil {
+		c.err = err
+		return
+	}
+	// Hold back the last block, which may be the final one with the padding
+	split := len(c.plain) - aes.BlockSize
+	c.next = append([]byte(nil), c.plain[split:]...)
+	c.plain = c.plain[:split]
+}
+
+func (c *cbcReader) finish() {
+	c.err = io.EOF
+	if len(c.plain) == 0 {
+		c.err = errors.New("empty cipher text")
+		return
+	}
+	padding := int(c.plain[len(c.plain)-1])
+	if padding == 0 || padding > aes.BlockSize || padding > len(c.plain) {
+		c.plain = nil
+		c.err = errors.New("invalid padding")
+		return
+	}
+	c.plain = c.plain[:len(c.plain)-padding]
+}
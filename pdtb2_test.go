@@ -0,0 +1,255 @@
+package dodp
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"text/template"
+)
+
+// Keys and documents of a protected book, generated once for all tests
+type pdtb2Fixture struct {
+	readingSystemKey *rsa.PrivateKey
+	bookKey          *rsa.PrivateKey
+	contentKey       []byte
+	kx               *KeyExchange
+	enc              *Encryption
+	plain            []byte
+}
+
+var (
+	pdtb2Once    sync.Once
+	pdtb2Data    *pdtb2Fixture
+	pdtb2DataErr error
+)
+
+func loadPDTB2Fixture(t *testing.T) *pdtb2Fixture {
+	t.Helper()
+	pdtb2Once.Do(func() {
+		pdtb2Data, pdtb2DataErr = newPDTB2Fixture()
+	})
+	if pdtb2DataErr != nil {
+		t.Fatal(pdtb2DataErr)
+	}
+	return pdtb2Data
+}
+
+func newPDTB2Fixture() (*pdtb2Fixture, error) {
+	f := &pdtb2Fixture{}
+	var err error
+	if f.readingSystemKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		return nil, err
+	}
+	if f.bookKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		return nil, err
+	}
+	if f.plain, err = os.ReadFile("testdata/chapter1.txt"); err != nil {
+		return nil, err
+	}
+
+	// The private key of the book is encrypted with a session key, which is encrypted with the key of the Reading System
+	sessionKey := randomBytes(16)
+	wrappedSessionKey, err := rsa.EncryptPKCS1v15(rand.Reader, &f.readingSystemKey.PublicKey, sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	encryptedBookKey, err := encryptCBC(sessionKey, pad(x509.MarshalPKCS1PrivateKey(f.bookKey)))
+	if err != nil {
+		return nil, err
+	}
+	f.kx = &KeyExchange{Keys: Keys{KeyPair: []KeyPair{{
+		Name: "book",
+		EncryptedKey: []EncryptedKey{{
+			EncryptionMethod: EncryptionMethod{Algorithm: AlgorithmRSA15},
+			CipherData:       CipherData{CipherValue: base64.StdEncoding.EncodeToString(wrappedSessionKey)},
+		}},
+		EncryptedData: []EncryptedData{{
+			EncryptionMethod: EncryptionMethod{Algorithm: AlgorithmAES128CBC},
+			CipherData:       CipherData{CipherValue: base64.StdEncoding.EncodeToString(encryptedBookKey)},
+		}},
+	}}}}
+
+	// The content key is encrypted with the public key of the book
+	f.contentKey = randomBytes(32)
+	wrappedContentKey, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, &f.bookKey.PublicKey, f.contentKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.ParseFiles("testdata/encryption.xml")
+	if err != nil {
+		return nil, err
+	}
+	var doc bytes.Buffer
+	if err := tmpl.Execute(&doc, map[string]string{"ContentKey": base64.StdEncoding.EncodeToString(wrappedContentKey)}); err != nil {
+		return nil, err
+	}
+	if f.enc, err = ParseEncryption(&doc); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// pad appends the padding of XML Encryption, whose last byte is its length
+func pad(data []byte) []byte {
+	n := aes.BlockSize - len(data)%aes.BlockSize
+	return append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+// encryptCBC encrypts the padded data and prepends the IV
+func encryptCBC(key, padded []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, aes.BlockSize+len(padded))
+	copy(out, randomBytes(aes.BlockSize))
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], padded)
+	return out, nil
+}
+
+func (f *pdtb2Fixture) keys(t *testing.T) *PDTB2Keys {
+	t.Helper()
+	keys, err := NewPDTB2Keys(f.kx, f.readingSystemKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func (f *pdtb2Fixture) encryptedContent(t *testing.T, padded []byte) []byte {
+	t.Helper()
+	data, err := encryptCBC(f.contentKey, padded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestNewPDTB2Keys(t *testing.T) {
+	f := loadPDTB2Fixture(t)
+	keys := f.keys(t)
+	if key, ok := keys.keys["book"]; !ok || !key.Equal(f.bookKey) {
+		t.Fatal("key pair of the book is not unwrapped")
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPDTB2Keys(f.kx, otherKey); err == nil {
+		t.Error("expected an error for a foreign key of the Reading System")
+	}
+}
+
+func TestPDTB2OpenResource(t *testing.T) {
+	f := loadPDTB2Fixture(t)
+	keys := f.keys(t)
+	body := f.encryptedContent(t, pad(f.plain))
+
+	r, err := keys.OpenResource(f.enc, Resource{LocalURI: "text/chapter1.txt"}, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, f.plain) {
+		t.Errorf("got %q, want %q", got, f.plain)
+	}
+
+	_, err = keys.OpenResource(f.enc, Resource{LocalURI: "text/chapter2.txt"}, bytes.NewReader(body))
+	if !errors.Is(err, ErrNotProtected) {
+		t.Errorf("got %v, want %v", err, ErrNotProtected)
+	}
+}
+
+func TestPDTB2SmallReads(t *testing.T) {
+	f := loadPDTB2Fixture(t)
+	keys := f.keys(t)
+	body := f.encryptedContent(t, pad(f.plain))
+
+	readers := map[string]func(io.Reader) io.Reader{
+		"OneByteReader": iotest.OneByteReader,
+		"HalfReader":    iotest.HalfReader,
+		"DataErrReader": iotest.DataErrReader,
+	}
+	for name, wrap := range readers {
+		r, err := keys.NewReader(&f.enc.EncryptedData[0], wrap(bytes.NewReader(body)))
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		if err := iotest.TestReader(r, f.plain); err != nil {
+			t.Errorf("%v: %v", name, err)
+		}
+	}
+}
+
+func TestPDTB2InvalidCipherText(t *testing.T) {
+	f := loadPDTB2Fixture(t)
+	keys := f.keys(t)
+	padded := pad(f.plain)
+
+	zeroPadding := append([]byte(nil), padded...)
+	zeroPadding[len(zeroPadding)-1] = 0
+	longPadding := append([]byte(nil), padded...)
+	longPadding[len(longPadding)-1] = aes.BlockSize + 1
+	body := f.encryptedContent(t, padded)
+
+	tests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{"zero padding", f.encryptedContent(t, zeroPadding), "invalid padding"},
+		{"padding longer than a block", f.encryptedContent(t, longPadding), "invalid padding"},
+		{"partial block", body[:len(body)-5], "multiple of the block size"},
+		{"IV only", body[:aes.BlockSize], "empty cipher text"},
+		{"truncated IV", body[:aes.BlockSize-1], "reading IV"},
+	}
+	for _, tt := range tests {
+		r, err := keys.NewReader(&f.enc.EncryptedData[0], bytes.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("%v: %v", tt.name, err)
+		}
+		_, err = io.ReadAll(r)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: got %v, want an error containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestPDTB2WrongKeySize(t *testing.T) {
+	f := loadPDTB2Fixture(t)
+	keys := f.keys(t)
+
+	// The content key has 32 bytes, which is not an AES-128 key
+	ed := f.enc.EncryptedData[0]
+	ed.EncryptionMethod.Algorithm = AlgorithmAES128CBC
+	if _, err := keys.NewReader(&ed, bytes.NewReader(nil)); err == nil || !strings.Contains(err.Error(), "invalid key size") {
+		t.Errorf("got %v, want an invalid key size error", err)
+	}
+
+	ed.EncryptionMethod.Algorithm = "http://www.w3.org/2001/04/xmlenc#tripledes-cbc"
+	if _, err := keys.NewReader(&ed, bytes.NewReader(nil)); err == nil || !strings.Contains(err.Error(), "unsupported data encryption algorithm") {
+		t.Errorf("got %v, want an unsupported algorithm error", err)
+	}
+}
@@ -0,0 +1,254 @@
+package dodp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const (
+	daisyOnlineNS = "http://www.daisy.org/ns/daisy-online/"
+	bookmarkNS    = "http://www.daisy.org/z3986/2005/bookmark/"
+)
+
+// Profile controls the details of SOAP messages that differ between DODP service implementations.
+// The zero value is the default profile.
+type Profile struct {
+	Name string
+	// SOAPAction returns the value of the SOAPAction header for the operation. If nil, "/" followed by the operation name is used.
+	SOAPAction func(action string) string
+	// PrefixNamespaces makes all elements written with namespace prefixes (soap, do, bm) instead of default namespace declarations.
+	PrefixNamespaces bool
+	// OmitXMLDeclaration removes the XML declaration from the beginning of requests.
+	OmitXMLDeclaration bool
+	// SOAP12 makes the client use SOAP 1.2 instead of SOAP 1.1.
+	// The action is passed in the Content-Type header, and faults are expected in the SOAP 1.2 format, although SOAP 1.1 responses are accepted too.
+	SOAP12 bool
+}
+
+// Compatibility profiles
+var (
+	// The profile used by default. It works with most DODP services.
+	DefaultProfile = Profile{Name: "default"}
+	// Services built on ASP.NET and WCF expect the quoted namespace URI in the SOAPAction header.
+	DotNetProfile = Profile{
+		Name:       "dotnet",
+		SOAPAction: QuotedURIAction,
+	}
+	// Services built on Java SOAP stacks such as Axis and CXF, which also may not handle default namespace declarations.
+	JavaProfile = Profile{
+		Name:             "java",
+		SOAPAction:       QuotedURIAction,
+		PrefixNamespaces: true,
+	}
+	// Services that accept only SOAP 1.2.
+	SOAP12Profile = Profile{
+		Name:   "soap12",
+		SOAP12: true,
+	}
+)
+
+var profiles = map[string]Profile{
+	DefaultProfile.Name: DefaultProfile,
+	DotNetProfile.Name:  DotNetProfile,
+	JavaProfile.Name:    JavaProfile,
+	SOAP12Profile.Name:  SOAP12Profile,
+}
+
+// LookupProfile returns the compatibility profile with the specified name: default, dotnet, java or soap12.
+func LookupProfile(name string) (Profile, bool) {
+	p, ok := profiles[name]
+	return p, ok
+}
+
+// URIAction forms the SOAPAction from the DODP namespace URI and the operation name, e.g. http://www.daisy.org/ns/daisy-online/logOn
+func URIAction(action string) string {
+	return daisyOnlineNS + action
+}
+
+// QuotedURIAction is like URIAction, but the value is enclosed in double quotes as required by SOAP 1.1.
+func QuotedURIAction(action string) string {
+	return strconv.Quote(URIAction(action))
+}
+
+// WithProfile sets the compatibility profile of the client.
+func WithProfile(profile Profile) Option {
+	return func(c *Client) error {
+		c.profile = profile
+		return nil
+	}
+}
+
+// WithProfileName sets the compatibility profile of the client by its name.
+func WithProfileName(name string) Option {
+	return func(c *Client) error {
+		profile, ok := LookupProfile(name)
+		if !ok {
+			return fmt.Errorf("unknown profile: %v", name)
+		}
+		c.profile = profile
+		return nil
+	}
+}
+
+// WithSOAP12 makes the client use SOAP 1.2 with the current profile.
+// It should be specified after WithProfile, which replaces the whole profile.
+func WithSOAP12() Option {
+	return func(c *Client) error {
+		c.profile.SOAP12 = true
+		return nil
+	}
+}
+
+func (p *Profile) soapAction(action string) string {
+	if p.SOAPAction == nil {
+		return "/" + action
+	}
+	return p.SOAPAction(action)
+}
+
+// setHeaders sets the HTTP headers that identify the SOAP version and the operation
+func (p *Profile) setHeaders(h http.Header, action string) {
+	soapAction := p.soapAction(action)
+	if p.SOAP12 {
+		if unquoted, err := strconv.Unquote(soapAction); err == nil {
+			soapAction = unquoted
+		}
+		h.Set("Content-Type", "application/soap+xml; charset=utf-8; action="+strconv.Quote(soapAction))
+		h.Set("Accept", "application/soap+xml, text/xml")
+		return
+	}
+	h.Set("Content-Type", "text/xml; charset=utf-8")
+	h.Set("Accept", "text/xml")
+	h.Set("SOAPAction", soapAction)
+}
+
+func (p *Profile) envelopeNS() string {
+	if p.SOAP12 {
+		return soap12EnvelopeNS
+	}
+	return soapEnvelopeNS
+}
+
+// marshal returns the SOAP envelope with the specified header blocks and content formed according to the profile
+func (p *Profile) marshal(blocks []HeaderBlock, content any) ([]byte, error) {
+	var reqEnv envelope
+	reqEnv.XMLName = xml.Name{Space: p.envelopeNS(), Local: "Envelope"}
+	if len(blocks) != 0 {
+		reqEnv.Header = &header{Blocks: p.headerBlocks(blocks)}
+	}
+	reqEnv.Body.Content = content
+
+	msg, err := xml.Marshal(reqEnv)
+	if err != nil {
+		return nil, err
+	}
+	if p.PrefixNamespaces {
+		if msg, err = prefixNamespaces(msg); err != nil {
+			return nil, err
+		}
+	}
+	if p.OmitXMLDeclaration {
+		return msg, nil
+	}
+	return append([]byte(xml.Header), msg...), nil
+}
+
+// Prefixes of the well-known namespaces. Other namespaces get generated prefixes.
+var namespacePrefixes = map[string]string{
+	soapEnvelopeNS:   "soap",
+	soap12EnvelopeNS: "soap",
+	daisyOnlineNS:    "do",
+	bookmarkNS:       "bm",
+	keyExchangeNS:    "kx",
+	xmlEncNS:         "xenc",
+	xmlDSigNS:        "ds",
+}
+
+// prefixNamespaces rewrites the XML document produced by encoding/xml, replacing the default namespace declarations with prefixes.
+// All namespaces are declared on the root element.
+func prefixNamespaces(doc []byte) ([]byte, error) {
+	var tokens []xml.Token
+	prefixes := make(map[string]string)
+	var order []string
+	generated := 0
+	addNS := func(ns string) {
+		if ns == "" || ns == "xmlns" || prefixes[ns] != "" {
+			return
+		}
+		prefix := namespacePrefixes[ns]
+		if prefix == "" {
+			generated++
+			prefix = "ns" + strconv.Itoa(generated)
+		}
+		prefixes[ns] = prefix
+		order = append(order, ns)
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if se, ok := token.(xml.StartElement); ok {
+			addNS(se.Name.Space)
+			for _, attr := range se.Attr {
+				if attr.Name.Space != "xmlns" && attr.Name.Local != "xmlns" {
+					addNS(attr.Name.Space)
+				}
+			}
+		}
+		tokens = append(tokens, xml.CopyToken(token))
+	}
+
+	qname := func(name xml.Name) string {
+		if name.Space == "" {
+			return name.Local
+		}
+		return prefixes[name.Space] + ":" + name.Local
+	}
+
+	buf := &bytes.Buffer{}
+	root := true
+	for _, token := range tokens {
+		switch t := token.(type) {
+		case xml.StartElement:
+			buf.WriteString("<" + qname(t.Name))
+			if root {
+				for _, ns := range order {
+					buf.WriteString(" xmlns:" + prefixes[ns] + `="`)
+					xml.EscapeText(buf, []byte(ns))
+					buf.WriteString(`"`)
+				}
+				root = false
+			}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				buf.WriteString(" " + qname(attr.Name) + `="`)
+				xml.EscapeText(buf, []byte(attr.Value))
+				buf.WriteString(`"`)
+			}
+			buf.WriteString(">")
+		case xml.EndElement:
+			buf.WriteString("</" + qname(t.Name) + ">")
+		case xml.CharData:
+			xml.EscapeText(buf, t)
+		case xml.Comment, xml.ProcInst, xml.Directive:
+			// encoding/xml does not produce them inside the envelope
+		}
+	}
+	if root {
+		return nil, errors.New("empty SOAP envelope")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package dodp
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+)
+
+func TestPrefixNamespaces(t *testing.T) {
+	token := HeaderBlock{XMLName: xml.Name{Space: "urn:example:session", Local: "token"}, Content: "abc", MustUnderstand: true}
+	trace := HeaderBlock{XMLName: xml.Name{Space: "urn:example:trace", Local: "trace"}, Content: "1"}
+	bookmarkSet := &BookmarkSet{UID: "book1", Lastmark: Lastmark{NcxRef: "ncx1", URI: "a.smil#p1", TimeOffset: "00:00:10"}}
+
+	tests := []struct {
+		profile Profile
+		action  string
+		op      func(c *Client, ctx context.Context) error
+		want    string
+	}{
+		{
+			profile: JavaProfile,
+			action:  "setBookmarks",
+			op: func(c *Client, ctx context.Context) error {
+				_, err := c.SetBookmarksContext(ctx, "book1", bookmarkSet)
+				return err
+			},
+			want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:ns1="urn:example:session" xmlns:ns2="urn:example:trace" xmlns:do="http://www.daisy.org/ns/daisy-online/" xmlns:bm="http://www.daisy.org/z3986/2005/bookmark/">` +
+				`<soap:Header><ns1:token soap:mustUnderstand="1">abc</ns1:token><ns2:trace>1</ns2:trace></soap:Header>` +
+				`<soap:Body><do:setBookmarks><do:contentID>book1</do:contentID>` +
+				`<bm:bookmarkSet><bm:title><bm:text></bm:text></bm:title><bm:uid>book1</bm:uid><bm:lastmark><bm:ncxRef>ncx1</bm:ncxRef><bm:URI>a.smil#p1</bm:URI><bm:timeOffset>00:00:10</bm:timeOffset><bm:charOffset></bm:charOffset></bm:lastmark></bm:bookmarkSet>` +
+				`</do:setBookmarks></soap:Body></soap:Envelope>`,
+		},
+		{
+			profile: JavaProfile,
+			action:  "logOn",
+			op: func(c *Client, ctx context.Context) error {
+				_, err := c.LogOnContext(ctx, "user", "secret")
+				return err
+			},
+			want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:ns1="urn:example:session" xmlns:ns2="urn:example:trace" xmlns:do="http://www.daisy.org/ns/daisy-online/">` +
+				`<soap:Header><ns1:token soap:mustUnderstand="1">abc</ns1:token><ns2:trace>1</ns2:trace></soap:Header>` +
+				`<soap:Body><do:logOn><do:username>user</do:username><do:password>***</do:password></do:logOn></soap:Body></soap:Envelope>`,
+		},
+		{
+			profile: DotNetProfile,
+			action:  "logOn",
+			op: func(c *Client, ctx context.Context) error {
+				_, err := c.LogOnContext(ctx, "user", "secret")
+				return err
+			},
+			want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/">` +
+				`<Header><token xmlns="urn:example:session" xmlns:envelope="http://schemas.xmlsoap.org/soap/envelope/" envelope:mustUnderstand="1">abc</token><trace xmlns="urn:example:trace">1</trace></Header>` +
+				`<Body><logOn xmlns="http://www.daisy.org/ns/daisy-online/"><username>user</username><password>***</password></logOn></Body></Envelope>`,
+		},
+	}
+	for _, tt := range tests {
+		recorder := &Recorder{}
+		c, err := NewClientWithOptions("http://localhost/", WithProfile(tt.profile), WithHeaderBlocks(token, trace), WithDryRun(recorder))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tt.op(c, context.Background()); !errors.Is(err, ErrDryRun) {
+			t.Fatalf("%v %v: %v", tt.profile.Name, tt.action, err)
+		}
+		requests := recorder.Requests()
+		if len(requests) != 1 {
+			t.Fatalf("%v %v: got %v requests, want 1", tt.profile.Name, tt.action, len(requests))
+		}
+		if got := string(requests[0].Envelope); got != tt.want {
+			t.Errorf("%v %v: got\n%v\nwant\n%v", tt.profile.Name, tt.action, got, tt.want)
+		}
+	}
+}
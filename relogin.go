@@ -0,0 +1,140 @@
+package dodp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// State of the transparent session re-establishment
+type relogin struct {
+	mu                      sync.Mutex
+	enabled                 bool
+	hook                    func(action string, err error)
+	creds                   *Credentials
+	readingSystemAttributes *ReadingSystemAttributes
+	session                 *Session
+}
+
+// Operations of the Session Initialization Sequence are never retried after re-establishing the session
+var sessionOperations = map[string]bool{
+	"logOn":                      true,
+	"logOff":                     true,
+	"getServiceAttributes":       true,
+	"setReadingSystemAttributes": true,
+}
+
+// WithAutoRelogin enables transparent re-establishment of the session.
+// See SetAutoRelogin for details.
+func WithAutoRelogin(hook func(action string, err error)) Option {
+	return func(c *Client) error {
+		c.SetAutoRelogin(true, hook)
+		return nil
+	}
+}
+
+// SetAutoRelogin enables or disables transparent re-establishment of the session.
+// When enabled, the client keeps in memory the credentials of the next successful logOn and the next accepted reading system attributes,
+// so the mode should be enabled before the session is started, e.g. with the WithAutoRelogin option. Disabling the mode forgets them.
+// If an operation fails with the noActiveSession fault, the Session Initialization Sequence is performed again and the operation is retried once.
+// The hook, if not nil, is called after each re-establishment with the name of the failed operation and the result of the sequence.
+func (c *Client) SetAutoRelogin(enabled bool, hook func(action string, err error)) {
+	c.relogin.mu.Lock()
+	defer c.relogin.mu.Unlock()
+	c.relogin.enabled = enabled
+	c.relogin.hook = hook
+	if !enabled {
+		c.relogin.creds = nil
+		c.relogin.readingSystemAttributes = nil
+	}
+}
+
+// The password is kept only while the mode is enabled
+func (r *relogin) setCredentials(creds *Credentials) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.enabled {
+		r.creds = creds
+	}
+}
+
+func (r *relogin) setReadingSystemAttributes(readingSystemAttributes *ReadingSystemAttributes) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.enabled {
+		r.readingSystemAttributes = readingSystemAttributes
+	}
+}
+
+func (r *relogin) setSession(session *Session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.session = session
+}
+
+// reset forgets the state of a deliberately ended session, which must not be restored
+func (r *relogin) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.creds = nil
+	r.readingSystemAttributes = nil
+	r.session = nil
+}
+
+// callWithRelogin performs the call and, if the session has expired, re-establishes it and repeats the call once
+func (c *Client) callWithRelogin(ctx context.Context, action string, call func() error) error {
+	err := call()
+	if err == nil || !errors.Is(err, ErrNoActiveSession) || sessionOperations[action] {
+		return err
+	}
+
+	c.relogin.mu.Lock()
+	if !c.relogin.enabled || c.relogin.creds == nil {
+		c.relogin.mu.Unlock()
+		return err
+	}
+	creds := *c.relogin.creds
+	readingSystemAttributes := c.relogin.readingSystemAttributes
+	session := c.relogin.session
+	hook := c.relogin.hook
+	c.relogin.mu.Unlock()
+
+	reloginErr := c.restoreSession(ctx, creds, readingSystemAttributes, session)
+	if hook != nil {
+		hook(action, reloginErr)
+	}
+	if reloginErr != nil {
+		return errors.Join(err, fmt.Errorf("re-establishing session: %w", reloginErr))
+	}
+	return call()
+}
+
+func (c *Client) restoreSession(ctx context.Context, creds Credentials, readingSystemAttributes *ReadingSystemAttributes, session *Session) error {
+	ok, err := c.LogOnContext(ctx, creds.Username, creds.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLogOnRejected
+	}
+	serviceAttributes, err := c.GetServiceAttributesContext(ctx)
+	if err != nil {
+		return err
+	}
+	if session != nil {
+		// The Service properties may have changed since the session was started
+		session.setServiceAttributes(serviceAttributes)
+	}
+	if readingSystemAttributes == nil {
+		return nil
+	}
+	ok, err = c.SetReadingSystemAttributesContext(ctx, readingSystemAttributes)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrReadingSystemAttributesRejected
+	}
+	return nil
+}
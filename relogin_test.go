@@ -0,0 +1,127 @@
+package dodp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sessionService emulates a Service whose session expires after the Session Initialization Sequence
+type sessionService struct {
+	mu      sync.Mutex
+	actions []string
+	logOns  int
+	expired bool
+}
+
+func (s *sessionService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	action := strings.TrimPrefix(strings.Trim(r.Header.Get("SOAPAction"), `"`), "/")
+	s.mu.Lock()
+	s.actions = append(s.actions, action)
+	var body string
+	switch action {
+	case "logOn":
+		s.logOns++
+		s.expired = false
+		body = `<logOnResponse xmlns="http://www.daisy.org/ns/daisy-online/"><logOnResult>true</logOnResult></logOnResponse>`
+	case "getServiceAttributes":
+		// The second session reports a new property
+		body = fmt.Sprintf(`<getServiceAttributesResponse xmlns="http://www.daisy.org/ns/daisy-online/"><serviceAttributes><supportsSearch>%v</supportsSearch></serviceAttributes></getServiceAttributesResponse>`, s.logOns > 1)
+	case "setReadingSystemAttributes":
+		// The first session expires as soon as it is started
+		s.expired = s.logOns == 1
+		body = `<setReadingSystemAttributesResponse xmlns="http://www.daisy.org/ns/daisy-online/"><setReadingSystemAttributesResult>true</setReadingSystemAttributesResult></setReadingSystemAttributesResponse>`
+	case "getContentList":
+		if s.expired {
+			s.expired = false
+			s.mu.Unlock()
+			w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault><faultcode>s:Server</faultcode><faultstring>no active session</faultstring><detail><noActiveSessionFault xmlns="http://www.daisy.org/ns/daisy-online/"/></detail></s:Fault></s:Body></s:Envelope>`)
+			return
+		}
+		body = `<getContentListResponse xmlns="http://www.daisy.org/ns/daisy-online/"><contentList id="issued" totalItems="0" firstItem="0" lastItem="0"/></getContentListResponse>`
+	}
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>%v</s:Body></s:Envelope>`, body)
+}
+
+func TestAutoRelogin(t *testing.T) {
+	service := &sessionService{}
+	server := httptest.NewServer(service)
+	defer server.Close()
+
+	var hookActions []string
+	var hookErr error
+	c, err := NewClientWithOptions(server.URL, WithAutoRelogin(func(action string, err error) {
+		hookActions = append(hookActions, action)
+		hookErr = err
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := c.StartSession(Credentials{Username: "user", Password: "secret"}, &ReadingSystemAttributes{Manufacturer: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.ServiceAttributes().SupportsSearch {
+		t.Fatal("unexpected service attributes of the first session")
+	}
+
+	if _, err := session.GetContentList(Issued, 0, -1); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"logOn", "getServiceAttributes", "setReadingSystemAttributes",
+		"getContentList",
+		"logOn", "getServiceAttributes", "setReadingSystemAttributes",
+		"getContentList",
+	}
+	if got := strings.Join(service.actions, " "); got != strings.Join(want, " ") {
+		t.Errorf("got actions %v, want %v", got, strings.Join(want, " "))
+	}
+	if len(hookActions) != 1 || hookActions[0] != "getContentList" || hookErr != nil {
+		t.Errorf("got hook calls %v with error %v", hookActions, hookErr)
+	}
+	if !session.ServiceAttributes().SupportsSearch {
+		t.Error("service attributes of the session are not updated after re-establishing it")
+	}
+
+	c.SetAutoRelogin(false, nil)
+	if c.relogin.creds != nil {
+		t.Error("credentials are kept after disabling the relogin mode")
+	}
+}
+
+func TestAutoReloginDisabled(t *testing.T) {
+	service := &sessionService{}
+	server := httptest.NewServer(service)
+	defer server.Close()
+
+	c, err := NewClientWithOptions(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := c.StartSession(Credentials{Username: "user", Password: "secret"}, &ReadingSystemAttributes{Manufacturer: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.relogin.creds != nil {
+		t.Error("credentials are kept without the relogin mode")
+	}
+
+	// The mode enabled after the session is started has no credentials to use
+	c.SetAutoRelogin(true, nil)
+	if _, err := session.GetContentList(Issued, 0, -1); !errors.Is(err, ErrNoActiveSession) {
+		t.Errorf("got %v, want %v", err, ErrNoActiveSession)
+	}
+	if service.logOns != 1 {
+		t.Errorf("got %v logOn operations, want 1", service.logOns)
+	}
+}
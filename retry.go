@@ -0,0 +1,183 @@
+package dodp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Limit of the delay requested by the Retry-After header when the policy sets neither MaxRetryAfter nor MaxBackoff
+const defaultMaxRetryAfter = time.Minute
+
+// Operations that do not change the state of the Service and are safe to repeat
+var idempotentOperations = map[string]bool{
+	"getServiceAttributes":    true,
+	"getContentList":          true,
+	"getContentMetadata":      true,
+	"getContentResources":     true,
+	"getBookmarks":            true,
+	"getServiceAnnouncements": true,
+	"getKeyExchangeObject":    true,
+}
+
+// RetryPolicy describes how the client repeats operations that failed because of transient errors.
+// Only the operations that are safe to repeat are retried: getServiceAttributes, getContentList, getContentMetadata, getContentResources, getBookmarks, getServiceAnnouncements and getKeyExchangeObject.
+// Transient errors are network timeouts, refused and reset connections, the 429, 502, 503 and 504 HTTP statuses and the internalServerError fault. Other faults are never retried.
+type RetryPolicy struct {
+	// Maximum number of attempts, including the first one. Values less than 2 disable retries.
+	MaxAttempts int
+	// Delay before the first retry. Each next delay is doubled and a random jitter is applied.
+	InitialBackoff time.Duration
+	// Upper limit of the delay between attempts. Zero means no limit.
+	MaxBackoff time.Duration
+	// Upper limit of the delay requested by the Retry-After header of a 503 response. If the Service asks to wait longer, the operation is not retried.
+	// Zero means MaxBackoff, or one minute if MaxBackoff is zero too.
+	MaxRetryAfter time.Duration
+	// Additional operations that may be retried, e.g. "issueContent" or "returnContent".
+	ExtraOperations []string
+}
+
+// WithRetryPolicy enables retrying of the operations according to the policy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) error {
+		if policy.MaxAttempts < 0 || policy.InitialBackoff < 0 || policy.MaxBackoff < 0 || policy.MaxRetryAfter < 0 {
+			return errors.New("invalid retry policy")
+		}
+		c.retry = &policy
+		return nil
+	}
+}
+
+func (p *RetryPolicy) allows(action string) bool {
+	if idempotentOperations[action] {
+		return true
+	}
+	for _, op := range p.ExtraOperations {
+		if op == action {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RetryPolicy) maxRetryAfter() time.Duration {
+	switch {
+	case p.MaxRetryAfter != 0:
+		return p.MaxRetryAfter
+	case p.MaxBackoff != 0:
+		return p.MaxBackoff
+	}
+	return defaultMaxRetryAfter
+}
+
+// maxDelay returns the upper limit of the delay before the specified retry, counting from zero
+func (p *RetryPolicy) maxDelay(retry int) time.Duration {
+	d := p.InitialBackoff
+	for i := 0; i < retry && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		// Without MaxBackoff the doubling stops at the largest duration instead of overflowing
+		if d > math.MaxInt64/2 {
+			d = math.MaxInt64
+			break
+		}
+		d *= 2
+	}
+	if p.MaxBackoff != 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// backoff returns the delay before the specified retry, counting from zero
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	n := int64(p.maxDelay(retry))
+	if n == 0 {
+		return 0
+	}
+	if n < math.MaxInt64 {
+		n++
+	}
+	// Full jitter spreads the retries of many Reading Systems after an outage of the Service
+	return time.Duration(rand.Int63n(n))
+}
+
+func (c *Client) callWithRetry(ctx context.Context, action string, call func() error) error {
+	p := c.retry
+	if p == nil || p.MaxAttempts < 2 || !p.allows(action) {
+		return call()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= p.MaxAttempts || ctx.Err() != nil || !isTransient(err) {
+			return err
+		}
+
+		delay := p.backoff(attempt - 1)
+		if d, ok := retryAfter(err); ok {
+			if d > p.maxRetryAfter() {
+				return err
+			}
+			delay = d
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+func isTransient(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var fault *Fault
+	if errors.As(err, &fault) {
+		return errors.Is(fault, ErrInternalServerError)
+	}
+	// The request did not reach the Service or the response was lost
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryAfter returns the delay requested by the Retry-After header of a 503 response
+func retryAfter(err error) (time.Duration, bool) {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	value := httpErr.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
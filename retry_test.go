@@ -0,0 +1,81 @@
+package dodp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyMaxDelay(t *testing.T) {
+	tests := []struct {
+		policy RetryPolicy
+		retry  int
+		want   time.Duration
+	}{
+		{RetryPolicy{InitialBackoff: time.Second}, 0, time.Second},
+		{RetryPolicy{InitialBackoff: time.Second}, 3, 8 * time.Second},
+		{RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}, 3, 5 * time.Second},
+		{RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}, 1000, 5 * time.Second},
+		{RetryPolicy{InitialBackoff: time.Second}, 33, 1 << 33 * time.Second},
+		{RetryPolicy{InitialBackoff: time.Second}, 34, math.MaxInt64},
+		{RetryPolicy{InitialBackoff: time.Second}, 1000, math.MaxInt64},
+		{RetryPolicy{}, 10, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.maxDelay(tt.retry); got != tt.want {
+			t.Errorf("%+v, retry %v: got %v, want %v", tt.policy, tt.retry, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second}
+	for retry := 0; retry < 100; retry++ {
+		if d := p.backoff(retry); d < 0 || d > p.maxDelay(retry) {
+			t.Fatalf("retry %v: delay %v is out of range", retry, d)
+		}
+	}
+}
+
+func TestCallWithRetry(t *testing.T) {
+	retryAfter := func(value string) error {
+		header := make(http.Header)
+		header.Set("Retry-After", value)
+		return &HTTPError{StatusCode: http.StatusServiceUnavailable, Header: header}
+	}
+	tests := []struct {
+		name   string
+		action string
+		err    error
+		calls  int
+	}{
+		{"internal server error", "getContentList", &Fault{Faultcode: "s:internalServerError"}, 3},
+		{"non-transient fault", "getContentList", &Fault{Faultcode: "s:invalidParameter"}, 1},
+		{"bad gateway", "getContentList", &HTTPError{StatusCode: http.StatusBadGateway}, 3},
+		{"not found", "getContentList", &HTTPError{StatusCode: http.StatusNotFound}, 1},
+		{"Retry-After within the limit", "getContentList", retryAfter("0"), 3},
+		{"Retry-After above the limit", "getContentList", retryAfter("3600"), 1},
+		{"other error", "getContentList", errors.New("boom"), 1},
+		{"not idempotent operation", "issueContent", &Fault{Faultcode: "s:internalServerError"}, 1},
+	}
+	for _, tt := range tests {
+		c, err := NewClientWithOptions("http://localhost/", WithRetryPolicy(RetryPolicy{MaxAttempts: 3, MaxBackoff: time.Millisecond}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		calls := 0
+		err = c.callWithRetry(context.Background(), tt.action, func() error {
+			calls++
+			return tt.err
+		})
+		if err != tt.err {
+			t.Errorf("%v: got %v, want %v", tt.name, err, tt.err)
+		}
+		if calls != tt.calls {
+			t.Errorf("%v: got %v calls, want %v", tt.name, calls, tt.calls)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package dodp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Errors returned when a step of the session sequence is rejected by the service
+var (
+	ErrLogOnRejected                   = errors.New("logOn rejected by the service")
+	ErrReadingSystemAttributesRejected = errors.New("setReadingSystemAttributes rejected by the service")
+	ErrLogOffRejected                  = errors.New("logOff rejected by the service")
+)
+
+// Credentials of the User for the logOn operation
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Session is a DAISY Online session established by the Session Initialization Sequence.
+// All Client operations are available through it.
+type Session struct {
+	*Client
+	mu                sync.Mutex
+	serviceAttributes *ServiceAttributes
+}
+
+// StartSession performs the Session Initialization Sequence: logOn, getServiceAttributes and setReadingSystemAttributes.
+// If any of the steps fails after a successful logOn, the Reading System is logged off.
+func (c *Client) StartSession(creds Credentials, readingSystemAttributes *ReadingSystemAttributes) (*Session, error) {
+	return c.StartSessionContext(c.ctx, creds, readingSystemAttributes)
+}
+
+// StartSessionContext is like StartSession but uses ctx instead of the client context.
+func (c *Client) StartSessionContext(ctx context.Context, creds Credentials, readingSystemAttributes *ReadingSystemAttributes) (*Session, error) {
+	ok, err := c.LogOnContext(ctx, creds.Username, creds.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLogOnRejected
+	}
+
+	serviceAttributes, err := c.GetServiceAttributesContext(ctx)
+	if err != nil {
+		c.LogOffContext(ctx)
+		return nil, err
+	}
+
+	ok, err = c.SetReadingSystemAttributesContext(ctx, readingSystemAttributes)
+	if err == nil && !ok {
+		err = ErrReadingSystemAttributesRejected
+	}
+	if err != nil {
+		c.LogOffContext(ctx)
+		return nil, err
+	}
+
+	session := &Session{Client: c, serviceAttributes: serviceAttributes}
+	c.relogin.setSession(session)
+	return session, nil
+}
+
+// ServiceAttributes returns the Service properties received during the Session Initialization Sequence.
+// If the session has been transparently re-established, the properties received at that time are returned.
+func (s *Session) ServiceAttributes() *ServiceAttributes {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.serviceAttributes
+}
+
+func (s *Session) setServiceAttributes(serviceAttributes *ServiceAttributes) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serviceAttributes = serviceAttributes
+}
+
+// Close ends the session by logging the Reading System off the Service.
+func (s *Session) Close() error {
+	return s.CloseContext(s.ctx)
+}
+
+// CloseContext is like Close but uses ctx instead of the client context.
+func (s *Session) CloseContext(ctx context.Context) error {
+	ok, err := s.LogOffContext(ctx)
+	if err != nil {
+		return fmt.Errorf("closing session: %w", err)
+	}
+	if !ok {
+		return ErrLogOffRejected
+	}
+	return nil
+}
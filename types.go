@@ -32,8 +32,8 @@ type SupportedUplinkAudioCodecs struct {
 
 // A list of  Content Selection Methods  supported by this Service. A Service must support at least one of the two methods.
 type SupportedContentSelectionMethods struct {
-	XMLName xml.Name `xml:"supportedContentSelectionMethods"`
-	Method  []string `xml:"method"`
+	XMLName xml.Name          `xml:"supportedContentSelectionMethods"`
+	Method  []SelectionMethod `xml:"method"`
 }
 
 // The identity of the Service.
@@ -53,10 +53,10 @@ type ServiceProvider struct {
 // A multi-purpose label, containing text and optionally audio.
 // To achieve maximum interoperability, Services should support the provision of audio labels, as Reading Systems may require them in order to render Service messages to the user.
 type Label struct {
-	XMLName xml.Name `xml:"label"`
-	Lang    string   `xml:"lang,attr"`
-	Dir     string   `xml:"dir,attr"`
-	Text    string   `xml:"text"`
+	XMLName xml.Name  `xml:"label"`
+	Lang    string    `xml:"lang,attr"`
+	Dir     Direction `xml:"dir,attr"`
+	Text    string    `xml:"text"`
 	Audio   Audio
 }
 
@@ -91,14 +91,14 @@ type Config struct {
 
 // Specifies which Content protection (Digital Rights Management) standards the Reading System supports, if any.
 type SupportedContentProtectionFormats struct {
-	XMLName          xml.Name `xml:"supportedContentProtectionFormats"`
-	ProtectionFormat []string `xml:"protectionFormat"`
+	XMLName          xml.Name           `xml:"supportedContentProtectionFormats"`
+	ProtectionFormat []ProtectionFormat `xml:"protectionFormat"`
 }
 
 // Specifies which Content formats the Reading System supports. A Service may use this information to choose which formats to offer to the Reading System. This document does not specify the behavior of the Service if this list is empty.
 type SupportedContentFormats struct {
-	XMLName       xml.Name `xml:"supportedContentFormats"`
-	ContentFormat []string `xml:"contentFormat"`
+	XMLName       xml.Name        `xml:"supportedContentFormats"`
+	ContentFormat []ContentFormat `xml:"contentFormat"`
 }
 
 type SupportedMimeTypes struct {
@@ -117,8 +117,8 @@ type SupportedInputTypes struct {
 }
 
 type Input struct {
-	XMLName xml.Name `xml:"input"`
-	Type    string   `xml:"type,attr"`
+	XMLName xml.Name  `xml:"input"`
+	Type    InputType `xml:"type,attr"`
 }
 
 type ContentList struct {
@@ -140,32 +140,32 @@ type ContentItem struct {
 
 type ContentMetadata struct {
 	XMLName        xml.Name `xml:"contentMetadata"`
-	Category       string   `xml:"category,attr"`
+	Category       Category `xml:"category,attr"`
 	RequiresReturn bool     `xml:"requiresReturn,attr"`
 	Sample         Sample
 	Metadata       Metadata
 }
 
 type Metadata struct {
-	XMLName     xml.Name `xml:"metadata"`
-	Title       string   `xml:"title"`
-	Identifier  string   `xml:"identifier"`
-	Publisher   string   `xml:"publisher"`
-	Format      string   `xml:"format"`
-	Date        string   `xml:"date"`
-	Source      string   `xml:"source"`
-	Type        []string `xml:"type"`
-	Subject     []string `xml:"subject"`
-	Rights      []string `xml:"rights"`
-	Relation    []string `xml:"relation"`
-	Language    []string `xml:"language"`
-	Description []string `xml:"description"`
-	Creator     []string `xml:"creator"`
-	Coverage    []string `xml:"coverage"`
-	Contributor []string `xml:"contributor"`
-	Narrator    []string `xml:"narrator"`
-	Size        int64    `xml:"size"`
-	Meta        []Meta   `xml:"meta"`
+	XMLName     xml.Name      `xml:"metadata"`
+	Title       string        `xml:"title"`
+	Identifier  string        `xml:"identifier"`
+	Publisher   string        `xml:"publisher"`
+	Format      ContentFormat `xml:"format"`
+	Date        string        `xml:"date"`
+	Source      string        `xml:"source"`
+	Type        []string      `xml:"type"`
+	Subject     []string      `xml:"subject"`
+	Rights      []string      `xml:"rights"`
+	Relation    []string      `xml:"relation"`
+	Language    []string      `xml:"language"`
+	Description []string      `xml:"description"`
+	Creator     []string      `xml:"creator"`
+	Coverage    []string      `xml:"coverage"`
+	Contributor []string      `xml:"contributor"`
+	Narrator    []string      `xml:"narrator"`
+	Size        int64         `xml:"size"`
+	Meta        []Meta        `xml:"meta"`
 }
 
 type Meta struct {
@@ -255,10 +255,10 @@ type Announcements struct {
 }
 
 type Announcement struct {
-	XMLName  xml.Name `xml:"announcement"`
-	ID       string   `xml:"id,attr"`
-	Type     string   `xml:"type,attr"`
-	Priority int32    `xml:"priority,attr"`
+	XMLName  xml.Name         `xml:"announcement"`
+	ID       string           `xml:"id,attr"`
+	Type     AnnouncementType `xml:"type,attr"`
+	Priority int32            `xml:"priority,attr"`
 	Label    Label
 }
 